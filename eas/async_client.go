@@ -0,0 +1,312 @@
+package eas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pai-eas/eas-golang-sdk/eas/types"
+)
+
+// RequestIdTag is the tag of results in sink queue carrying the request id of their input.
+const RequestIdTag = "requestId"
+
+// Defaults of AsyncInferenceClient.
+const (
+	DefaultAsyncResultTTL   = time.Minute
+	DefaultAsyncWatchWindow = 100
+)
+
+var (
+	// ErrAsyncTimeout is returned by Wait when the result does not arrive within the timeout of request.
+	ErrAsyncTimeout = errors.New("timeout waiting for async result")
+	// ErrAsyncClosed is returned by Submit and Wait once the AsyncInferenceClient is closed.
+	ErrAsyncClosed = errors.New("async inference client is closed")
+)
+
+type asyncOptions struct {
+	timeout   time.Duration
+	resultTTL time.Duration
+	window    uint64
+}
+
+// AsyncOption customizes AsyncInferenceClient.
+type AsyncOption func(*asyncOptions)
+
+// WithAsyncTimeout sets how long a submitted request waits for its result, counted from Submit.
+// Requests wait until the context of Wait is done by default.
+func WithAsyncTimeout(timeout time.Duration) AsyncOption {
+	return func(o *asyncOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithAsyncResultTTL sets how long the results nobody waits for are kept, one minute by default. Results
+// may arrive before Submit returns, or after their requests are timed out or cancelled.
+func WithAsyncResultTTL(ttl time.Duration) AsyncOption {
+	return func(o *asyncOptions) {
+		o.resultTTL = ttl
+	}
+}
+
+// WithAsyncWatchWindow sets the window of the sink watcher, 100 by default.
+func WithAsyncWatchWindow(window uint64) AsyncOption {
+	return func(o *asyncOptions) {
+		o.window = window
+	}
+}
+
+// AsyncInferenceClient puts requests into the input queue of an async service, and matches the results
+// watched from its sink queue by request id. A single watcher on the sink queue is shared by all the
+// outstanding requests, results are committed once they are received, so a sink queue should not be
+// watched by other consumers of the same group.
+type AsyncInferenceClient struct {
+	input *QueueClient
+	sink  *QueueClient
+	opts  asyncOptions
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu        sync.Mutex
+	pending   map[string]*AsyncRequest
+	unclaimed map[string]unclaimedResult
+}
+
+type unclaimedResult struct {
+	frame    types.DataFrame
+	received time.Time
+}
+
+// AsyncRequest is the handle of a submitted request.
+type AsyncRequest struct {
+	// Index is the index of request in input queue.
+	Index uint64
+	// RequestId identifies the request and its result.
+	RequestId string
+
+	client   *AsyncInferenceClient
+	deadline time.Time
+	done     chan struct{}
+	result   types.DataFrame
+	err      error
+}
+
+// NewAsyncInferenceClient starts watching the sink queue for results, the client should be closed after use.
+func NewAsyncInferenceClient(input, sink *QueueClient, opts ...AsyncOption) (*AsyncInferenceClient, error) {
+	o := asyncOptions{resultTTL: DefaultAsyncResultTTL, window: DefaultAsyncWatchWindow}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.resultTTL <= 0 || o.window == 0 {
+		return nil, fmt.Errorf("result ttl and watch window should be positive")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	watcher, err := sink.Watch(ctx, 0, o.window, false, true)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	c := &AsyncInferenceClient{
+		input:     input,
+		sink:      sink,
+		opts:      o,
+		ctx:       ctx,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+		pending:   map[string]*AsyncRequest{},
+		unclaimed: map[string]unclaimedResult{},
+	}
+	go c.run(watcher)
+	return c, nil
+}
+
+// Submit puts data with tags into the input queue, and returns the handle to wait for its result.
+func (c *AsyncInferenceClient) Submit(ctx context.Context, data []byte, tags types.Tags) (*AsyncRequest, error) {
+	return c.SubmitWithPriority(ctx, data, tags, 0)
+}
+
+// SubmitWithPriority is like Submit, the request is put with priority.
+func (c *AsyncInferenceClient) SubmitWithPriority(ctx context.Context, data []byte, tags types.Tags, prio types.Priority) (*AsyncRequest, error) {
+	if c.ctx.Err() != nil {
+		return nil, ErrAsyncClosed
+	}
+	start := time.Now()
+	index, requestId, err := c.input.PutWithPriority(ctx, data, tags, prio)
+	if err != nil {
+		return nil, err
+	}
+	if len(requestId) == 0 {
+		return nil, fmt.Errorf("no request id returned for index %d", index)
+	}
+	req := &AsyncRequest{Index: index, RequestId: requestId, client: c, done: make(chan struct{})}
+	if c.opts.timeout > 0 {
+		req.deadline = start.Add(c.opts.timeout)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ctx.Err() != nil {
+		return nil, ErrAsyncClosed
+	}
+	// the result may have arrived before the request id is known
+	if result, ok := c.unclaimed[requestId]; ok {
+		delete(c.unclaimed, requestId)
+		req.result, req.err = result.frame, resultError(result.frame)
+		close(req.done)
+		return req, nil
+	}
+	c.pending[requestId] = req
+	return req, nil
+}
+
+// Wait waits for the result of request, which is the data frame put into sink queue by the service.
+// It returns ErrAsyncTimeout once the timeout of request is reached, the error of ctx if ctx is done
+// first, in which case Wait can be called again.
+func (r *AsyncRequest) Wait(ctx context.Context) (types.DataFrame, error) {
+	var timeout <-chan time.Time
+	if !r.deadline.IsZero() {
+		timer := time.NewTimer(time.Until(r.deadline))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-r.done:
+		return r.result, r.err
+	case <-timeout:
+		r.client.complete(r, ErrAsyncTimeout)
+		<-r.done
+		return r.result, r.err
+	case <-ctx.Done():
+		return types.DataFrame{}, ctx.Err()
+	}
+}
+
+// Cancel stops waiting for the result, which is dropped when it arrives later.
+func (r *AsyncRequest) Cancel() {
+	r.client.complete(r, context.Canceled)
+}
+
+// complete finishes the request with err unless it is finished already.
+func (c *AsyncInferenceClient) complete(req *AsyncRequest, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pending[req.RequestId] != req {
+		return
+	}
+	delete(c.pending, req.RequestId)
+	req.err = err
+	close(req.done)
+}
+
+// Pending returns the number of requests waiting for results.
+func (c *AsyncInferenceClient) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.pending)
+}
+
+// Close stops watching the sink queue, the requests still waiting fail with ErrAsyncClosed.
+func (c *AsyncInferenceClient) Close() {
+	c.cancel()
+	<-c.done
+}
+
+func (c *AsyncInferenceClient) run(watcher types.Watcher) {
+	defer close(c.done)
+	ticker := time.NewTicker(c.opts.resultTTL / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case frame, ok := <-watcher.FrameChan():
+			if ok {
+				c.dispatch(frame)
+				continue
+			}
+			// the watcher is broken, watch again until the client is closed
+			watcher = c.rewatch()
+			if watcher == nil {
+				c.closePending()
+				return
+			}
+		case now := <-ticker.C:
+			c.expire(now)
+		case <-c.ctx.Done():
+			watcher.Close()
+			c.closePending()
+			return
+		}
+	}
+}
+
+func (c *AsyncInferenceClient) rewatch() types.Watcher {
+	for {
+		select {
+		case <-c.ctx.Done():
+			return nil
+		case <-time.After(time.Second):
+		}
+		watcher, err := c.sink.Watch(c.ctx, 0, c.opts.window, false, true)
+		if err == nil {
+			return watcher
+		}
+	}
+}
+
+func (c *AsyncInferenceClient) dispatch(frame types.DataFrame) {
+	requestId := frame.Tags.Get(RequestIdTag)
+	if len(requestId) == 0 {
+		// error messages of watcher, or frames not produced by the service
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if req, ok := c.pending[requestId]; ok {
+		delete(c.pending, requestId)
+		req.result, req.err = frame, resultError(frame)
+		close(req.done)
+		return
+	}
+	c.unclaimed[requestId] = unclaimedResult{frame: frame, received: time.Now()}
+}
+
+// resultError returns the error of result frame failed to be received, e.g. its blob is missing.
+func resultError(frame types.DataFrame) error {
+	if len(frame.Message) == 0 {
+		return nil
+	}
+	return fmt.Errorf("receive result of frame %d: %s", frame.Index.Uint64(), frame.Message)
+}
+
+// expire times out requests and drops unclaimed results, requests are also timed out in Wait, here are
+// the ones nobody waits for.
+func (c *AsyncInferenceClient) expire(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for requestId, result := range c.unclaimed {
+		if now.Sub(result.received) >= c.opts.resultTTL {
+			delete(c.unclaimed, requestId)
+		}
+	}
+	for requestId, req := range c.pending {
+		if !req.deadline.IsZero() && now.After(req.deadline) {
+			delete(c.pending, requestId)
+			req.err = ErrAsyncTimeout
+			close(req.done)
+		}
+	}
+}
+
+func (c *AsyncInferenceClient) closePending() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for requestId, req := range c.pending {
+		delete(c.pending, requestId)
+		req.err = ErrAsyncClosed
+		close(req.done)
+	}
+	c.unclaimed = map[string]unclaimedResult{}
+}
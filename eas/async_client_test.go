@@ -0,0 +1,134 @@
+package eas
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pai-eas/eas-golang-sdk/eas/internal/queuetest"
+	"github.com/pai-eas/eas-golang-sdk/eas/types"
+)
+
+func newTestQueueClient(t *testing.T, server *queuetest.Server, opts ...QueueOption) *QueueClient {
+	client, err := NewQueueClient(server.URL, "", "token", append([]QueueOption{WithBasePath("")}, opts...)...)
+	assertNoError(t, err)
+	client.WebsocketWatch = false
+	return client
+}
+
+func newTestAsyncClient(t *testing.T, opts ...AsyncOption) (*AsyncInferenceClient, *queuetest.Server, *queuetest.Server) {
+	input, sink := queuetest.NewServer(), queuetest.NewServer()
+	t.Cleanup(input.Close)
+	t.Cleanup(sink.Close)
+	client, err := NewAsyncInferenceClient(newTestQueueClient(t, input), newTestQueueClient(t, sink), opts...)
+	assertNoError(t, err)
+	t.Cleanup(client.Close)
+	return client, input, sink
+}
+
+func TestAsyncSubmitWait(t *testing.T) {
+	client, input, sink := newTestAsyncClient(t)
+	ctx := context.Background()
+
+	var requests []*AsyncRequest
+	for _, data := range []string{"a", "b", "c"} {
+		req, err := client.Submit(ctx, []byte(data), types.Tags{"model": "m"})
+		assertNoError(t, err)
+		requests = append(requests, req)
+	}
+	assertEqual(t, input.Puts(), 3)
+	// results arrive out of order
+	for i := len(requests) - 1; i >= 0; i-- {
+		frames := input.Frames()
+		sink.Put(append([]byte("result-"), frames[i].Data...), types.Tags{RequestIdTag: requests[i].RequestId})
+	}
+	for i, data := range []string{"a", "b", "c"} {
+		result, err := requests[i].Wait(ctx)
+		assertNoError(t, err)
+		assertEqual(t, string(result.Data), "result-"+data)
+	}
+	assertEqual(t, client.Pending(), 0)
+
+	// the result arriving before Submit returns is claimed as well
+	sink.Put([]byte("early"), types.Tags{RequestIdTag: "req-4"})
+	req, err := client.Submit(ctx, []byte("d"), nil)
+	assertNoError(t, err)
+	assertEqual(t, req.RequestId, "req-4")
+	result, err := req.Wait(ctx)
+	assertNoError(t, err)
+	assertEqual(t, string(result.Data), "early")
+}
+
+func TestAsyncTimeoutAndCleanup(t *testing.T) {
+	client, _, sink := newTestAsyncClient(t, WithAsyncTimeout(50*time.Millisecond), WithAsyncResultTTL(40*time.Millisecond))
+	ctx := context.Background()
+
+	req, err := client.Submit(ctx, []byte("a"), nil)
+	assertNoError(t, err)
+	waitCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err = req.Wait(waitCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expect deadline exceeded, got %v", err)
+	}
+	if _, err = req.Wait(ctx); err != ErrAsyncTimeout {
+		t.Fatalf("expect ErrAsyncTimeout, got %v", err)
+	}
+	assertEqual(t, client.Pending(), 0)
+
+	// the late result is never claimed and dropped after its ttl
+	sink.Put([]byte("late"), types.Tags{RequestIdTag: req.RequestId})
+	unclaimed := func() int {
+		client.mu.Lock()
+		defer client.mu.Unlock()
+		return len(client.unclaimed)
+	}
+	if !queuetest.WaitFor(time.Second, func() bool { return unclaimed() == 1 }) {
+		t.Fatal("result was not received")
+	}
+	if !queuetest.WaitFor(time.Second, func() bool { return unclaimed() == 0 }) {
+		t.Fatal("unclaimed result was not dropped")
+	}
+
+	// requests nobody waits for are timed out as well
+	_, err = client.Submit(ctx, []byte("b"), nil)
+	assertNoError(t, err)
+	if !queuetest.WaitFor(time.Second, func() bool { return client.Pending() == 0 }) {
+		t.Fatal("pending request was not timed out")
+	}
+}
+
+func TestAsyncClose(t *testing.T) {
+	client, _, _ := newTestAsyncClient(t)
+	ctx := context.Background()
+
+	req, err := client.Submit(ctx, []byte("a"), nil)
+	assertNoError(t, err)
+	client.Close()
+	if _, err = req.Wait(ctx); err != ErrAsyncClosed {
+		t.Fatalf("expect ErrAsyncClosed, got %v", err)
+	}
+	if _, err = client.Submit(ctx, []byte("b"), nil); err != ErrAsyncClosed {
+		t.Fatalf("expect ErrAsyncClosed, got %v", err)
+	}
+}
+
+func TestAsyncUnreadableResult(t *testing.T) {
+	input, sink := queuetest.NewServer(), queuetest.NewServer()
+	defer input.Close()
+	defer sink.Close()
+	store, err := NewLocalBlobStore(t.TempDir())
+	assertNoError(t, err)
+	client, err := NewAsyncInferenceClient(newTestQueueClient(t, input), newTestQueueClient(t, sink, WithClaimCheck(store, 1024)))
+	assertNoError(t, err)
+	defer client.Close()
+	ctx := context.Background()
+
+	req, err := client.Submit(ctx, []byte("a"), nil)
+	assertNoError(t, err)
+	sink.Put(nil, types.Tags{RequestIdTag: req.RequestId, BlobKeyTag: "missing"})
+	if _, err = req.Wait(ctx); err == nil || !strings.Contains(err.Error(), "blob not found") {
+		t.Fatalf("expect the error of missing blob, got %v", err)
+	}
+}
@@ -0,0 +1,239 @@
+package eas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pai-eas/eas-golang-sdk/eas/types"
+)
+
+// Tags referring to the payload offloaded into BlobStore by QueueClient with claim check enabled.
+const (
+	BlobKeyTag  = "blob.key"
+	BlobSizeTag = "blob.size"
+)
+
+// blobKeyTTL limits how long the blob of a received frame is remembered, frames neither acknowledged
+// nor negatived by this client in time, e.g. abandoned by the consumer, leave their blobs in store.
+const blobKeyTTL = time.Hour
+
+// ErrBlobNotFound is returned by BlobStore when the key does not exist.
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore stores the payloads offloaded from queue, implementations must be safe for concurrent use.
+type BlobStore interface {
+	// Put stores data by key.
+	Put(ctx context.Context, key string, data []byte) error
+	// Get returns the data stored by key, or ErrBlobNotFound.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete removes the data stored by key, deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// WithClaimCheck stores payloads larger than threshold bytes into store, and puts frames with empty data
+// referring to them by the blob.key tag instead. The payloads are fetched back in Get and Watch, and
+// deleted from store once their frames are committed or deleted by this client, or at once if they are
+// got with autoDelete or watched with autocommit.
+func WithClaimCheck(store BlobStore, threshold int) QueueOption {
+	return func(o *queueOptions) {
+		o.blobStore = store
+		o.blobThreshold = threshold
+	}
+}
+
+// WithBlobErrorHandler sets the function called with the blobs failed to be deleted after their frames
+// are acknowledged, which are left in store. Errors are written by the standard logger by default.
+func WithBlobErrorHandler(onError func(key string, err error)) QueueOption {
+	return func(o *queueOptions) {
+		o.onBlobError = onError
+	}
+}
+
+type blobKey struct {
+	key     string
+	claimed time.Time
+}
+
+// blobKeys remembers the blobs of received frames, until they are acknowledged or negatived, or
+// expire after blobKeyTTL.
+type blobKeys struct {
+	mu   sync.Mutex
+	keys map[uint64]blobKey
+}
+
+func (b *blobKeys) add(index uint64, key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	if b.keys == nil {
+		b.keys = map[uint64]blobKey{}
+	}
+	for i, k := range b.keys {
+		if now.Sub(k.claimed) > blobKeyTTL {
+			delete(b.keys, i)
+		}
+	}
+	b.keys[index] = blobKey{key: key, claimed: now}
+}
+
+// take returns and forgets the blobs of indexes.
+func (b *blobKeys) take(indexes []uint64) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var keys []string
+	for _, index := range indexes {
+		if k, ok := b.keys[index]; ok {
+			keys = append(keys, k.key)
+			delete(b.keys, index)
+		}
+	}
+	return keys
+}
+
+// offload stores data into the blob store, it returns the tags of frame referring to it.
+func (q *QueueClient) offload(ctx context.Context, data []byte, tags types.Tags) (types.Tags, error) {
+	key := uuid.New().String()
+	if err := q.blobStore.Put(ctx, key, data); err != nil {
+		return nil, fmt.Errorf("offload payload into blob store: %v", err)
+	}
+	refTags := types.Tags{}
+	for k, v := range tags {
+		refTags[k] = v
+	}
+	refTags[BlobKeyTag] = key
+	refTags[BlobSizeTag] = strconv.Itoa(len(data))
+	return refTags, nil
+}
+
+// claim fetches the payload of frame referring to a blob, the blob tags are removed from frame. The blob
+// is deleted at once if the frame is removed from queue already by autoDelete.
+func (q *QueueClient) claim(ctx context.Context, df types.DataFrame, autoDelete bool) (types.DataFrame, error) {
+	key := df.Tags.Get(BlobKeyTag)
+	if len(key) == 0 {
+		return df, nil
+	}
+	data, err := q.blobStore.Get(ctx, key)
+	if err != nil {
+		return df, fmt.Errorf("claim payload of frame %d: %w", df.Index.Uint64(), err)
+	}
+	claimed := types.DataFrame{Index: df.Index, Data: data, Tags: types.Tags{}, Message: df.Message}
+	for k, v := range df.Tags {
+		if k != BlobKeyTag && k != BlobSizeTag {
+			claimed.Tags[k] = v
+		}
+	}
+	if autoDelete {
+		q.deleteBlob(ctx, key)
+	} else {
+		q.blobs.add(df.Index.Uint64(), key)
+	}
+	return claimed, nil
+}
+
+// cleanupBlobs deletes the blobs of frames committed or deleted, failures are reported to onBlobError
+// only since the frames are acknowledged already.
+func (q *QueueClient) cleanupBlobs(ctx context.Context, indexes []uint64) {
+	if q.blobStore == nil {
+		return
+	}
+	for _, key := range q.blobs.take(indexes) {
+		q.deleteBlob(ctx, key)
+	}
+}
+
+func (q *QueueClient) deleteBlob(ctx context.Context, key string) {
+	err := q.blobStore.Delete(ctx, key)
+	if err == nil {
+		return
+	}
+	if q.onBlobError != nil {
+		q.onBlobError(key, err)
+	} else {
+		log.Printf("failed to delete blob %s: %v", key, err)
+	}
+}
+
+// forgetBlobs forgets the blobs of frames negatived, which are kept for the next delivery.
+func (q *QueueClient) forgetBlobs(indexes []uint64) {
+	if q.blobStore != nil {
+		q.blobs.take(indexes)
+	}
+}
+
+// LocalBlobStore stores blobs as files in a directory, e.g. a file system shared by producers and
+// consumers.
+type LocalBlobStore struct {
+	dir string
+}
+
+// NewLocalBlobStore creates the blob store in dir, which is created if absent.
+func NewLocalBlobStore(dir string) (*LocalBlobStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &LocalBlobStore{dir: dir}, nil
+}
+
+func (s *LocalBlobStore) path(key string) (string, error) {
+	if len(key) == 0 || strings.Contains(key, "..") || strings.ContainsAny(key, `/\`) {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(s.dir, key), nil
+}
+
+// Put writes data into a temporary file and renames it to the key, so a blob is never read partially.
+func (s *LocalBlobStore) Put(ctx context.Context, key string, data []byte) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(s.dir, ".tmp-"+key)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(data); err == nil {
+		err = f.Close()
+	} else {
+		f.Close()
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+func (s *LocalBlobStore) Get(ctx context.Context, key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s: %w", key, ErrBlobNotFound)
+	}
+	return data, err
+}
+
+func (s *LocalBlobStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(path); os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,221 @@
+package eas
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Config configures S3BlobStore for Amazon S3 or other services compatible with its API, e.g. OSS
+// or MinIO.
+type S3Config struct {
+	// Endpoint is the URL of service, e.g. "https://s3.us-east-1.amazonaws.com".
+	Endpoint string
+	Region   string
+	Bucket   string
+	// Prefix is prepended to the keys of objects, e.g. "eas-queue/".
+	Prefix          string
+	AccessKeyId     string
+	SecretAccessKey string
+	// SessionToken is required by temporary credentials only.
+	SessionToken string
+	// PathStyle addresses the bucket by path "endpoint/bucket/key" rather than the virtual host
+	// "bucket.endpoint/key", as MinIO requires.
+	PathStyle bool
+	// HTTPClient sends requests, http.DefaultClient by default.
+	HTTPClient *http.Client
+}
+
+// S3BlobStore stores blobs as objects of S3 compatible services, requests are signed by AWS Signature
+// Version 4.
+type S3BlobStore struct {
+	config   S3Config
+	endpoint *url.URL
+	now      func() time.Time
+}
+
+// NewS3BlobStore creates the blob store of the bucket.
+func NewS3BlobStore(config S3Config) (*S3BlobStore, error) {
+	if len(config.Bucket) == 0 || len(config.Region) == 0 {
+		return nil, fmt.Errorf("bucket and region are required")
+	}
+	if len(config.AccessKeyId) == 0 || len(config.SecretAccessKey) == 0 {
+		return nil, fmt.Errorf("access key id and secret are required")
+	}
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if len(endpoint.Scheme) == 0 || len(endpoint.Host) == 0 {
+		return nil, fmt.Errorf("invalid endpoint %q", config.Endpoint)
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	return &S3BlobStore{config: config, endpoint: endpoint, now: time.Now}, nil
+}
+
+func (s *S3BlobStore) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	key = s.config.Prefix + key
+	if s.config.PathStyle {
+		key = s.config.Bucket + "/" + key
+	} else {
+		u.Host = s.config.Bucket + "." + u.Host
+	}
+	// the path is escaped as it is signed
+	segments := strings.Split(key, "/")
+	for i := range segments {
+		segments[i] = sigV4Escape(segments[i])
+	}
+	base := strings.TrimSuffix(u.Path, "/")
+	u.Path = base + "/" + key
+	u.RawPath = base + "/" + strings.Join(segments, "/")
+	return &u
+}
+
+func (s *S3BlobStore) do(ctx context.Context, method, key string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key).String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, body)
+	return s.config.HTTPClient.Do(req)
+}
+
+func (s *S3BlobStore) Put(ctx context.Context, key string, data []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("put object %s, unexpected status code: %d, message: %s", key, resp.StatusCode, readMessage(resp.Body))
+	}
+	return nil
+}
+
+func (s *S3BlobStore) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return ioutil.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%s: %w", key, ErrBlobNotFound)
+	default:
+		return nil, fmt.Errorf("get object %s, unexpected status code: %d, message: %s", key, resp.StatusCode, readMessage(resp.Body))
+	}
+}
+
+func (s *S3BlobStore) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("delete object %s, unexpected status code: %d, message: %s", key, resp.StatusCode, readMessage(resp.Body))
+	}
+	return nil
+}
+
+const (
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	sigV4TimeFormat = "20060102T150405Z"
+)
+
+// sign signs the request by AWS Signature Version 4 with the authorization header, the host, range,
+// content-type and x-amz-* headers are signed.
+func (s *S3BlobStore) sign(req *http.Request, body []byte) {
+	now := s.now().UTC()
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", now.Format(sigV4TimeFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if len(s.config.SessionToken) > 0 {
+		req.Header.Set("X-Amz-Security-Token", s.config.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "range" || lower == "content-type" {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	date := now.Format("20060102")
+	scope := date + "/" + s.config.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{sigV4Algorithm, now.Format(sigV4TimeFormat), scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretAccessKey), date)
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, s.config.AccessKeyId, scope, signedHeaders, signature))
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, key := range keys {
+		values := append([]string{}, query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, sigV4Escape(key)+"="+sigV4Escape(value))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// sigV4Escape escapes all characters except the unreserved ones of RFC 3986.
+func sigV4Escape(s string) string {
+	return strings.Replace(url.QueryEscape(s), "+", "%20", -1)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
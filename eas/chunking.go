@@ -0,0 +1,199 @@
+package eas
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pai-eas/eas-golang-sdk/eas/types"
+)
+
+// Tags of the chunks of payload split by QueueClient with chunking enabled, the first chunk is the head
+// of payload which is delivered as the reassembled frame.
+const (
+	ChunkIdTag    = "chunk.id"
+	ChunkSeqTag   = "chunk.seq"
+	ChunkTotalTag = "chunk.total"
+)
+
+// chunkWaitTimeout limits how long the chunks following a head are waited for.
+const chunkWaitTimeout = 10 * time.Second
+
+// PayloadTooLargeError is returned by Put when the payload exceeds the meta.maxPayloadBytes attribute
+// of queue, and chunking is not enabled.
+type PayloadTooLargeError struct {
+	Size  int
+	Limit int
+}
+
+func (e *PayloadTooLargeError) Error() string {
+	return fmt.Sprintf("payload of %d bytes exceeds the limit of queue %d bytes", e.Size, e.Limit)
+}
+
+// WithChunking splits payloads exceeding the meta.maxPayloadBytes attribute of queue into chunks instead
+// of failing with PayloadTooLargeError, they are reassembled in Get and Watch, where the reassembled
+// frame has the index of the first chunk, and committing it commits all its chunks. Chunks are fetched
+// by their tag once the first chunk is received, so frames should be watched without autocommit.
+func WithChunking() QueueOption {
+	return func(o *queueOptions) {
+		o.chunking = true
+	}
+}
+
+// chunkGroups remembers the chunk indexes of reassembled frames, until they are acknowledged.
+type chunkGroups struct {
+	mu      sync.Mutex
+	indexes map[uint64][]uint64
+}
+
+func (g *chunkGroups) add(head uint64, indexes []uint64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.indexes == nil {
+		g.indexes = map[uint64][]uint64{}
+	}
+	g.indexes[head] = indexes
+}
+
+// expand replaces the indexes of reassembled frames with all their chunks, which are forgotten.
+func (g *chunkGroups) expand(indexes []uint64) []uint64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.indexes) == 0 {
+		return indexes
+	}
+	expanded := make([]uint64, 0, len(indexes))
+	for _, index := range indexes {
+		if chunks, ok := g.indexes[index]; ok {
+			expanded = append(expanded, chunks...)
+			delete(g.indexes, index)
+		} else {
+			expanded = append(expanded, index)
+		}
+	}
+	return expanded
+}
+
+// maxPayloadBytes returns the payload limit of queue, 0 means unlimited.
+func (q *QueueClient) maxPayloadBytes() (int, error) {
+	attr, err := q.getAttr(false)
+	if err != nil {
+		return 0, err
+	}
+	limit, err := strconv.Atoi(attr[types.MaxPayloadBytes])
+	if err != nil || limit < 0 {
+		return 0, nil
+	}
+	return limit, nil
+}
+
+// putChunks puts data as chunks of the size, it returns the index and request id of the first chunk.
+func (q *QueueClient) putChunks(ctx context.Context, data []byte, tags types.Tags, prio types.Priority, size int) (uint64, string, error) {
+	total := (len(data) + size - 1) / size
+	id := uuid.New().String()
+	var index uint64
+	var requestId string
+	for seq := 0; seq < total; seq++ {
+		chunkTags := types.Tags{}
+		for key, val := range tags {
+			chunkTags[key] = val
+		}
+		chunkTags[ChunkIdTag] = id
+		chunkTags[ChunkSeqTag] = strconv.Itoa(seq)
+		chunkTags[ChunkTotalTag] = strconv.Itoa(total)
+		end := (seq + 1) * size
+		if end > len(data) {
+			end = len(data)
+		}
+		chunk := data[seq*size : end]
+		body, encoding, err := q.compressPayload(chunk)
+		if err != nil {
+			return index, requestId, err
+		}
+		if len(body) > size {
+			// incompressible chunks may grow by compression
+			body, encoding = chunk, ""
+		}
+		i, r, err := q.put(ctx, body, encoding, chunkTags, prio)
+		if err != nil {
+			return index, requestId, fmt.Errorf("put chunk %d of %d: %v", seq, total, err)
+		}
+		if seq == 0 {
+			index, requestId = i, r
+		}
+	}
+	return index, requestId, nil
+}
+
+// chunkInfo parses the chunk tags of frame, ok is false if it is not a chunk.
+func chunkInfo(frame types.DataFrame) (id string, seq, total int, ok bool) {
+	id = frame.Tags.Get(ChunkIdTag)
+	if len(id) == 0 {
+		return "", 0, 0, false
+	}
+	seq, err1 := strconv.Atoi(frame.Tags.Get(ChunkSeqTag))
+	total, err2 := strconv.Atoi(frame.Tags.Get(ChunkTotalTag))
+	return id, seq, total, err1 == nil && err2 == nil && seq >= 0 && seq < total
+}
+
+// assembleFrame reassembles the head of chunked frame, keep is false for the chunks following heads,
+// which are fetched along with their heads.
+func (q *QueueClient) assembleFrame(ctx context.Context, frame types.DataFrame, autoDelete bool) (types.DataFrame, bool, error) {
+	_, seq, _, ok := chunkInfo(frame)
+	if !ok {
+		return frame, true, nil
+	}
+	if seq != 0 {
+		return frame, false, nil
+	}
+	frame, err := q.assembleHead(ctx, frame, autoDelete)
+	return frame, true, err
+}
+
+// assembleHead fetches the chunks following the head, and joins them into one frame.
+func (q *QueueClient) assembleHead(ctx context.Context, head types.DataFrame, autoDelete bool) (types.DataFrame, error) {
+	id, _, total, _ := chunkInfo(head)
+	chunks := map[int]types.DataFrame{0: head}
+	deadline := time.Now().Add(chunkWaitTimeout)
+	for next := head.Index.Uint64() + 1; len(chunks) < total; {
+		frames, err := q.get(ctx, next, total, time.Second, autoDelete, types.Tags{ChunkIdTag: id})
+		if err != nil {
+			return head, fmt.Errorf("get chunks of frame %d: %v", head.Index.Uint64(), err)
+		}
+		for _, frame := range frames {
+			if _, seq, _, ok := chunkInfo(frame); ok {
+				chunks[seq] = frame
+			}
+		}
+		if len(frames) > 0 {
+			next = types.LargestIndex(frames).Uint64() + 1
+		} else if time.Now().After(deadline) {
+			return head, fmt.Errorf("frame %d: %d of %d chunks received", head.Index.Uint64(), len(chunks), total)
+		} else {
+			select {
+			case <-ctx.Done():
+				return head, ctx.Err()
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}
+
+	frame := types.DataFrame{Index: head.Index, Tags: types.Tags{}}
+	for key, val := range head.Tags {
+		if key != ChunkIdTag && key != ChunkSeqTag && key != ChunkTotalTag {
+			frame.Tags[key] = val
+		}
+	}
+	indexes := make([]uint64, 0, total)
+	for seq := 0; seq < total; seq++ {
+		frame.Data = append(frame.Data, chunks[seq].Data...)
+		indexes = append(indexes, chunks[seq].Index.Uint64())
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+	q.chunks.add(head.Index.Uint64(), indexes)
+	return frame, nil
+}
@@ -0,0 +1,133 @@
+package eas
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// CompressionNone disables compression of request bodies, it is the default.
+	CompressionNone = ""
+	// CompressionGzip compresses request bodies with gzip.
+	CompressionGzip = "gzip"
+	// CompressionZstd compresses request bodies with zstandard.
+	CompressionZstd = "zstd"
+
+	// DefaultCompressionThreshold is the minimum payload size in bytes to be compressed,
+	// smaller payloads are sent as is since compression rarely pays off for them.
+	DefaultCompressionThreshold = 1024
+)
+
+const (
+	headerContentEncoding = "Content-Encoding"
+	headerAcceptEncoding  = "Accept-Encoding"
+)
+
+var (
+	// zstd encoder and decoder are safe for concurrent use with EncodeAll and DecodeAll.
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+// validCompression checks whether the compression algorithm is supported.
+func validCompression(compression string) error {
+	switch compression {
+	case CompressionNone, CompressionGzip, CompressionZstd:
+		return nil
+	default:
+		return fmt.Errorf("unsupported compression: %s", compression)
+	}
+}
+
+// acceptEncoding returns the value of Accept-Encoding header advertised when compression is enabled.
+func acceptEncoding() string {
+	return CompressionZstd + ", " + CompressionGzip
+}
+
+// compress encodes data with the given algorithm if its size reaches the threshold,
+// it returns the encoded data and the content encoding actually applied.
+func compress(compression string, threshold int, data []byte) ([]byte, string, error) {
+	if compression == CompressionNone || len(data) < threshold {
+		return data, "", nil
+	}
+	switch compression {
+	case CompressionGzip:
+		buf := bytes.NewBuffer(make([]byte, 0, len(data)/2))
+		w := gzip.NewWriter(buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, "", err
+		}
+		if err := w.Close(); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), CompressionGzip, nil
+	case CompressionZstd:
+		return zstdEncoder.EncodeAll(data, make([]byte, 0, len(data)/2)), CompressionZstd, nil
+	default:
+		return nil, "", fmt.Errorf("unsupported compression: %s", compression)
+	}
+}
+
+// decompress decodes data according to the Content-Encoding header of a response.
+func decompress(encoding string, data []byte) ([]byte, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "", "identity":
+		return data, nil
+	case CompressionZstd:
+		return zstdDecoder.DecodeAll(data, nil)
+	default:
+		r, err := decompressReader(encoding, ioutil.NopCloser(bytes.NewReader(data)))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return ioutil.ReadAll(r)
+	}
+}
+
+// decompressReader wraps the reader of a response body to decode it according to
+// the Content-Encoding header, closing the returned reader closes the underlying one.
+func decompressReader(encoding string, r io.ReadCloser) (io.ReadCloser, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "", "identity":
+		return r, nil
+	case CompressionGzip:
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return &decodingReader{Reader: gr, closers: []func() error{gr.Close, r.Close}}, nil
+	case CompressionZstd:
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return &decodingReader{Reader: zr, closers: []func() error{
+			func() error { zr.Close(); return nil },
+			r.Close,
+		}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported content encoding: %s", encoding)
+	}
+}
+
+type decodingReader struct {
+	io.Reader
+	closers []func() error
+}
+
+func (d *decodingReader) Close() error {
+	var err error
+	for _, c := range d.closers {
+		if e := c(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
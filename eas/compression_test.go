@@ -0,0 +1,98 @@
+package eas
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pai-eas/eas-golang-sdk/eas/types"
+)
+
+func TestCompressRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte("eas compression "), 1024)
+	for _, compression := range []string{CompressionGzip, CompressionZstd} {
+		encoded, encoding, err := compress(compression, DefaultCompressionThreshold, data)
+		assertNoError(t, err)
+		assertEqual(t, encoding, compression)
+		if len(encoded) >= len(data) {
+			t.Fatalf("%s: compressed size %d is not smaller than %d", compression, len(encoded), len(data))
+		}
+		decoded, err := decompress(encoding, encoded)
+		assertNoError(t, err)
+		if !bytes.Equal(decoded, data) {
+			t.Fatalf("%s: round trip mismatch", compression)
+		}
+	}
+}
+
+func TestCompressThreshold(t *testing.T) {
+	data := []byte("small")
+	encoded, encoding, err := compress(CompressionGzip, DefaultCompressionThreshold, data)
+	assertNoError(t, err)
+	assertEqual(t, encoding, "")
+	if !bytes.Equal(encoded, data) {
+		t.Fatalf("payload under threshold should be sent as is")
+	}
+}
+
+func TestBytesPredictCompression(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 1000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Header.Get("Content-Encoding") != CompressionZstd {
+			t.Errorf("unexpected content encoding: %q", r.Header.Get("Content-Encoding"))
+		}
+		if r.Header.Get("Content-MD5") != md5sum(body) {
+			t.Errorf("signature is not computed over the compressed body")
+		}
+		plain, err := decompress(CompressionZstd, body)
+		if err != nil || !bytes.Equal(plain, data) {
+			t.Errorf("unexpected request body, err: %v", err)
+		}
+		encoded, _, _ := compress(CompressionGzip, 0, []byte("response"))
+		w.Header().Set("Content-Encoding", CompressionGzip)
+		w.Write(encoded)
+	}))
+	defer server.Close()
+
+	client := NewPredictClient(server.Listener.Addr().String(), "test")
+	client.SetToken("token")
+	client.SetCompression(CompressionZstd)
+	assertNoError(t, client.Init())
+	resp, err := client.BytesPredict(data)
+	assertNoError(t, err)
+	assertEqual(t, string(resp), "response")
+}
+
+func TestQueuePutCompression(t *testing.T) {
+	data := []byte(strings.Repeat("queue data ", 200))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("_attrs_") == "true" {
+			codec, _ := types.AttributesCodecFor(types.ContentTypeProtobuf)
+			codec.Encode(types.Attributes{types.UserIdentifyHeader: "X-User"}, w)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		plain, err := decompress(r.Header.Get("Content-Encoding"), body)
+		if err != nil || !bytes.Equal(plain, data) {
+			t.Errorf("unexpected request body, err: %v", err)
+		}
+		w.Write([]byte("1"))
+	}))
+	defer server.Close()
+
+	queue, err := NewQueueClient(server.URL, "test", "", WithCompression(CompressionGzip, 16))
+	assertNoError(t, err)
+	index, _, err := queue.Put(context.Background(), data, types.Tags{})
+	assertNoError(t, err)
+	assertEqual(t, index, uint64(1))
+
+	_, err = NewQueueClient(server.URL, "test", "", WithCompression("lz4", 16))
+	if err == nil {
+		t.Fatal("unsupported compression should be rejected")
+	}
+}
@@ -0,0 +1,151 @@
+package eas
+
+// Conversions between Go slices and the repeated fields of ArrayProto. Narrow integers, half
+// and bfloat16 are carried in int_val, the 16 bits floats as their raw bits zero-extended.
+// Complex numbers are carried in float_val or double_val as interleaved real and imaginary parts.
+
+func packInt8(content []int8) []int32 {
+	ret := make([]int32, len(content))
+	for i, v := range content {
+		ret[i] = int32(v)
+	}
+	return ret
+}
+
+func unpackInt8(vals []int32) []int8 {
+	ret := make([]int8, len(vals))
+	for i, v := range vals {
+		ret[i] = int8(v)
+	}
+	return ret
+}
+
+func packUint8(content []uint8) []int32 {
+	ret := make([]int32, len(content))
+	for i, v := range content {
+		ret[i] = int32(v)
+	}
+	return ret
+}
+
+func unpackUint8(vals []int32) []uint8 {
+	ret := make([]uint8, len(vals))
+	for i, v := range vals {
+		ret[i] = uint8(v)
+	}
+	return ret
+}
+
+func packInt16(content []int16) []int32 {
+	ret := make([]int32, len(content))
+	for i, v := range content {
+		ret[i] = int32(v)
+	}
+	return ret
+}
+
+func unpackInt16(vals []int32) []int16 {
+	ret := make([]int16, len(vals))
+	for i, v := range vals {
+		ret[i] = int16(v)
+	}
+	return ret
+}
+
+func packUint16(content []uint16) []int32 {
+	ret := make([]int32, len(content))
+	for i, v := range content {
+		ret[i] = int32(v)
+	}
+	return ret
+}
+
+func unpackUint16(vals []int32) []uint16 {
+	ret := make([]uint16, len(vals))
+	for i, v := range vals {
+		ret[i] = uint16(v)
+	}
+	return ret
+}
+
+func packHalf(content []float32) []int32 {
+	ret := make([]int32, len(content))
+	for i, v := range content {
+		ret[i] = int32(float32ToHalf(v))
+	}
+	return ret
+}
+
+func unpackHalf(vals []int32) []float32 {
+	ret := make([]float32, len(vals))
+	for i, v := range vals {
+		ret[i] = halfToFloat32(uint16(v))
+	}
+	return ret
+}
+
+func packBFloat16(content []float32) []int32 {
+	ret := make([]int32, len(content))
+	for i, v := range content {
+		ret[i] = int32(float32ToBFloat16(v))
+	}
+	return ret
+}
+
+func unpackBFloat16(vals []int32) []float32 {
+	ret := make([]float32, len(vals))
+	for i, v := range vals {
+		ret[i] = bfloat16ToFloat32(uint16(v))
+	}
+	return ret
+}
+
+func packBool(content []bool) []int32 {
+	ret := make([]int32, len(content))
+	for i, v := range content {
+		if v {
+			ret[i] = 1
+		}
+	}
+	return ret
+}
+
+func unpackBool(vals []int32) []bool {
+	ret := make([]bool, len(vals))
+	for i, v := range vals {
+		ret[i] = v != 0
+	}
+	return ret
+}
+
+func packComplex64(content []complex64) []float32 {
+	ret := make([]float32, 0, 2*len(content))
+	for _, v := range content {
+		ret = append(ret, real(v), imag(v))
+	}
+	return ret
+}
+
+func unpackComplex64(vals []float32) []complex64 {
+	ret := make([]complex64, len(vals)/2)
+	for i := range ret {
+		ret[i] = complex(vals[2*i], vals[2*i+1])
+	}
+	return ret
+}
+
+func packComplex128(content []complex128) []float64 {
+	ret := make([]float64, 0, 2*len(content))
+	for _, v := range content {
+		ret = append(ret, real(v), imag(v))
+	}
+	return ret
+}
+
+func unpackComplex128(vals []float64) []complex128 {
+	ret := make([]complex128, len(vals)/2)
+	for i := range ret {
+		ret[i] = complex(vals[2*i], vals[2*i+1])
+	}
+	return ret
+}
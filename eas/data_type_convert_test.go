@@ -0,0 +1,67 @@
+package eas
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTFRequestDataTypes(t *testing.T) {
+	req := TFRequest{}
+	req.AddFeedInt8("int8", []int64{3}, []int8{-128, 0, 127})
+	req.AddFeedUint16("uint16", []int64{2}, []uint16{0, 65535})
+	req.AddFeedHalf("half", []int64{2}, []float32{1.5, -0.25})
+	req.AddFeedBFloat16("bf16", []int64{2}, []float32{1, -3.5})
+	req.AddFeedComplex64("c64", []int64{2}, []complex64{complex(1, 2), complex(-3, 0.5)})
+	assertEqual(t, req.RequestData.Inputs["half"].Dtype, TfType_DT_HALF)
+
+	// echo the inputs back as outputs to check the getters
+	resp := TFResponse{}
+	resp.Response.Outputs = req.RequestData.Inputs
+	if !reflect.DeepEqual(resp.GetInt8Val("int8"), []int8{-128, 0, 127}) {
+		t.Fatalf("unexpected int8: %v", resp.GetInt8Val("int8"))
+	}
+	if !reflect.DeepEqual(resp.GetUint16Val("uint16"), []uint16{0, 65535}) {
+		t.Fatalf("unexpected uint16: %v", resp.GetUint16Val("uint16"))
+	}
+	if !reflect.DeepEqual(resp.GetHalfVal("half"), []float32{1.5, -0.25}) {
+		t.Fatalf("unexpected half: %v", resp.GetHalfVal("half"))
+	}
+	if !reflect.DeepEqual(resp.GetBFloat16Val("bf16"), []float32{1, -3.5}) {
+		t.Fatalf("unexpected bfloat16: %v", resp.GetBFloat16Val("bf16"))
+	}
+	if !reflect.DeepEqual(resp.GetComplex64Val("c64"), []complex64{complex(1, 2), complex(-3, 0.5)}) {
+		t.Fatalf("unexpected complex64: %v", resp.GetComplex64Val("c64"))
+	}
+	if len(resp.GetUint8Val("missing")) != 0 {
+		t.Fatal("missing output should be empty")
+	}
+}
+
+func TestTorchRequestDataTypes(t *testing.T) {
+	req := TorchRequest{}
+	req.AddFeedString(2, []int64{2}, [][]byte{[]byte("a"), []byte("b")})
+	req.AddFeedBool(0, []int64{3}, []bool{true, false, true})
+	req.AddFeedUint8(1, []int64{2}, []uint8{0, 255})
+	assertEqual(t, len(req.RequestData.Inputs), 3)
+	assertEqual(t, req.RequestData.Inputs[0].Dtype, TorchType_DT_BOOL)
+
+	resp := TorchResponse{}
+	resp.Response.Outputs = req.RequestData.Inputs
+	if !reflect.DeepEqual(resp.GetBoolVal(0), []bool{true, false, true}) {
+		t.Fatalf("unexpected bool: %v", resp.GetBoolVal(0))
+	}
+	if !reflect.DeepEqual(resp.GetUint8Val(1), []uint8{0, 255}) {
+		t.Fatalf("unexpected uint8: %v", resp.GetUint8Val(1))
+	}
+	assertEqual(t, string(resp.GetStringVal(2)[1]), "b")
+}
+
+func TestBFloat16Conversion(t *testing.T) {
+	for _, f := range []float32{0, 1, -2, 0.5, 3.140625} {
+		if got := bfloat16ToFloat32(float32ToBFloat16(f)); got != f {
+			t.Fatalf("bfloat16 round trip of %v got %v", f, got)
+		}
+	}
+	// 1 + 2^-8 is halfway between two bfloat16 values and rounds to even
+	assertEqual(t, bfloat16ToFloat32(float32ToBFloat16(1.00390625)), float32(1))
+}
@@ -0,0 +1,227 @@
+package eas
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/pai-eas/eas-golang-sdk/eas/types"
+)
+
+// Tags of the payloads encrypted by QueueClient with encryption enabled.
+const (
+	// EncryptionKeyIdTag is the id of the key encrypting the data key.
+	EncryptionKeyIdTag = "enc.keyId"
+	// EncryptionDataKeyTag is the data key encrypted by the key, in base64.
+	EncryptionDataKeyTag = "enc.dataKey"
+	// EncryptionEncodingTag is the compression applied to the payload before encryption, if any.
+	EncryptionEncodingTag = "enc.encoding"
+)
+
+// dataKeySize is the size of data keys, which are AES-256 keys.
+const dataKeySize = 32
+
+// ErrUnknownKey is wrapped by the errors of KeyProvider when the key id is unknown.
+var ErrUnknownKey = errors.New("unknown key id")
+
+// ErrUnencryptedFrame is wrapped by the DecryptionError of frames received without the encryption tags,
+// unless WithPlaintextAllowed is set.
+var ErrUnencryptedFrame = errors.New("frame is not encrypted")
+
+// KeyProvider provides the keys encrypting the data keys of payloads, implementations may fetch them
+// from a KMS and must be safe for concurrent use. Keys are 16, 24 or 32 bytes for AES-128, AES-192 or
+// AES-256.
+type KeyProvider interface {
+	// CurrentKey returns the key encrypting new payloads and its id.
+	CurrentKey(ctx context.Context) (id string, key []byte, err error)
+	// Key returns the key of id to decrypt payloads, or an error wrapping ErrUnknownKey.
+	Key(ctx context.Context, id string) ([]byte, error)
+}
+
+// StaticKeyProvider provides keys held in memory, rotated keys should be kept as long as the payloads
+// encrypted by them remain in queue.
+type StaticKeyProvider struct {
+	current string
+	keys    map[string][]byte
+}
+
+// NewStaticKeyProvider creates the provider of keys by id, payloads are encrypted by the current one.
+func NewStaticKeyProvider(current string, keys map[string][]byte) (*StaticKeyProvider, error) {
+	if _, ok := keys[current]; !ok {
+		return nil, fmt.Errorf("current key %q: %w", current, ErrUnknownKey)
+	}
+	copied := make(map[string][]byte, len(keys))
+	for id, key := range keys {
+		if _, err := aes.NewCipher(key); err != nil {
+			return nil, fmt.Errorf("key %q: %v", id, err)
+		}
+		copied[id] = key
+	}
+	return &StaticKeyProvider{current: current, keys: copied}, nil
+}
+
+func (p *StaticKeyProvider) CurrentKey(ctx context.Context) (string, []byte, error) {
+	return p.current, p.keys[p.current], nil
+}
+
+func (p *StaticKeyProvider) Key(ctx context.Context, id string) ([]byte, error) {
+	key, ok := p.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("key %q: %w", id, ErrUnknownKey)
+	}
+	return key, nil
+}
+
+// DecryptionError is the error decrypting the payload of frame.
+type DecryptionError struct {
+	Index uint64
+	KeyId string
+	Err   error
+}
+
+func (e *DecryptionError) Error() string {
+	if errors.Is(e.Err, ErrUnencryptedFrame) {
+		return fmt.Sprintf("decrypt frame %d: %v", e.Index, e.Err)
+	}
+	if errors.Is(e.Err, ErrUnknownKey) {
+		return fmt.Sprintf("decrypt frame %d: key %q is unknown to the key provider, it may have been rotated out: %v", e.Index, e.KeyId, e.Err)
+	}
+	return fmt.Sprintf("decrypt frame %d with key %q: %v", e.Index, e.KeyId, e.Err)
+}
+
+func (e *DecryptionError) Unwrap() error {
+	return e.Err
+}
+
+// WithEncryption encrypts the data put into queue by envelope encryption: each payload is encrypted by
+// a random data key with AES-GCM, and the data key is encrypted by the current key of provider. The key
+// id and the encrypted data key are recorded in tags, and payloads are decrypted in Get and Watch.
+// Frames without the tags fail with ErrUnencryptedFrame, see WithPlaintextAllowed. With WithCompression,
+// payloads are compressed before encryption and the encoding is recorded in the enc.encoding tag.
+func WithEncryption(provider KeyProvider) QueueOption {
+	return func(o *queueOptions) {
+		o.keyProvider = provider
+	}
+}
+
+// WithPlaintextAllowed delivers the frames without the encryption tags as they are, e.g. the frames put
+// before producers enable encryption, it should be removed once the migration is done.
+func WithPlaintextAllowed() QueueOption {
+	return func(o *queueOptions) {
+		o.allowPlaintext = true
+	}
+}
+
+// sealGCM encrypts plaintext with key, the nonce is prepended to the ciphertext.
+func sealGCM(key, plaintext, additionalData []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func openGCM(key, sealed, additionalData []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("ciphertext of %d bytes is too short", len(sealed))
+	}
+	return gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], additionalData)
+}
+
+// encrypt encrypts data, it returns the ciphertext and the tags of frame recording the keys.
+func (q *QueueClient) encrypt(ctx context.Context, data []byte, tags types.Tags) ([]byte, types.Tags, error) {
+	id, key, err := q.keyProvider.CurrentKey(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get current key: %v", err)
+	}
+	dataKey := make([]byte, dataKeySize)
+	if _, err = io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, nil, err
+	}
+	// the key id is authenticated with the data key
+	sealedKey, err := sealGCM(key, dataKey, []byte(id))
+	if err != nil {
+		return nil, nil, fmt.Errorf("encrypt data key with key %q: %v", id, err)
+	}
+	// the plaintext is compressed, the encoding is authenticated with the ciphertext
+	plaintext, encoding, err := compress(q.compression, q.compressionThreshold, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	ciphertext, err := sealGCM(dataKey, plaintext, []byte(encoding))
+	if err != nil {
+		return nil, nil, err
+	}
+	encTags := types.Tags{}
+	for k, v := range tags {
+		encTags[k] = v
+	}
+	encTags[EncryptionKeyIdTag] = id
+	encTags[EncryptionDataKeyTag] = base64.RawURLEncoding.EncodeToString(sealedKey)
+	if len(encoding) > 0 {
+		encTags[EncryptionEncodingTag] = encoding
+	}
+	return ciphertext, encTags, nil
+}
+
+// decrypt decrypts the payload of frame with the keys recorded in tags, which are removed from frame.
+func (q *QueueClient) decrypt(ctx context.Context, df types.DataFrame) (types.DataFrame, error) {
+	id, ok := df.Tags[EncryptionKeyIdTag]
+	if !ok {
+		if q.allowPlaintext {
+			return df, nil
+		}
+		return df, &DecryptionError{Index: df.Index.Uint64(), Err: ErrUnencryptedFrame}
+	}
+	fail := func(err error) (types.DataFrame, error) {
+		return df, &DecryptionError{Index: df.Index.Uint64(), KeyId: id, Err: err}
+	}
+	key, err := q.keyProvider.Key(ctx, id)
+	if err != nil {
+		return fail(err)
+	}
+	sealedKey, err := base64.RawURLEncoding.DecodeString(df.Tags.Get(EncryptionDataKeyTag))
+	if err != nil {
+		return fail(fmt.Errorf("invalid data key: %v", err))
+	}
+	dataKey, err := openGCM(key, sealedKey, []byte(id))
+	if err != nil {
+		return fail(fmt.Errorf("decrypt data key: %v", err))
+	}
+	encoding := df.Tags.Get(EncryptionEncodingTag)
+	data, err := openGCM(dataKey, df.Data, []byte(encoding))
+	if err != nil {
+		return fail(err)
+	}
+	if data, err = decompress(encoding, data); err != nil {
+		return fail(fmt.Errorf("decompress payload: %v", err))
+	}
+	decrypted := types.DataFrame{Index: df.Index, Data: data, Tags: types.Tags{}, Message: df.Message}
+	for k, v := range df.Tags {
+		if k != EncryptionKeyIdTag && k != EncryptionDataKeyTag && k != EncryptionEncodingTag {
+			decrypted.Tags[k] = v
+		}
+	}
+	return decrypted, nil
+}
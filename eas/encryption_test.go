@@ -0,0 +1,135 @@
+package eas
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pai-eas/eas-golang-sdk/eas/internal/queuetest"
+	"github.com/pai-eas/eas-golang-sdk/eas/types"
+)
+
+func TestEncryption(t *testing.T) {
+	key1, key2 := bytes.Repeat([]byte{1}, 32), bytes.Repeat([]byte{2}, 16)
+	oldKeys, err := NewStaticKeyProvider("k1", map[string][]byte{"k1": key1})
+	assertNoError(t, err)
+	newKeys, err := NewStaticKeyProvider("k2", map[string][]byte{"k1": key1, "k2": key2})
+	assertNoError(t, err)
+	server := queuetest.NewServer()
+	defer server.Close()
+	producer := newTestQueueClient(t, server, WithEncryption(oldKeys))
+	ctx := context.Background()
+
+	index, _, err := producer.Put(ctx, []byte("secret payload"), types.Tags{"k": "v"})
+	assertNoError(t, err)
+	frames := server.Frames()
+	if bytes.Contains(frames[0].Data, []byte("secret")) {
+		t.Fatalf("payload is not encrypted: %q", frames[0].Data)
+	}
+	assertEqual(t, frames[0].Tags[EncryptionKeyIdTag], "k1")
+
+	// payloads of the rotated key are decrypted as long as the provider keeps it
+	consumer := newTestQueueClient(t, server, WithEncryption(newKeys))
+	got, err := consumer.Get(ctx, index, 1, 0, false, types.Tags{})
+	assertNoError(t, err)
+	assertEqual(t, string(got[0].Data), "secret payload")
+	assertEqual(t, got[0].Tags.String(), "tags[k=v requestId=req-1]")
+
+	_, _, err = consumer.Put(ctx, []byte("new payload"), nil)
+	assertNoError(t, err)
+	watcher, err := consumer.Watch(ctx, 0, 10, false, false)
+	assertNoError(t, err)
+	defer watcher.Close()
+	frame := <-watcher.FrameChan()
+	assertEqual(t, string(frame.Data), "secret payload")
+	frame = <-watcher.FrameChan()
+	assertEqual(t, string(frame.Data), "new payload")
+
+	// the payload of key unknown to the provider fails with the key id
+	_, err = producer.Get(ctx, frame.Index.Uint64(), 1, 0, false, types.Tags{})
+	var decErr *DecryptionError
+	if !errors.As(err, &decErr) || !errors.Is(err, ErrUnknownKey) || decErr.KeyId != "k2" {
+		t.Fatalf("expect unknown key k2, got %v", err)
+	}
+
+	// tampered payloads fail to be authenticated
+	tampered := server.Frames()[1]
+	tampered.Data[len(tampered.Data)-1] ^= 1
+	server.Put(tampered.Data, tampered.Tags)
+	frame = <-watcher.FrameChan()
+	if !strings.Contains(frame.Message, "message authentication failed") {
+		t.Fatalf("unexpected frame: %+v", frame)
+	}
+
+	// plaintext frames are rejected unless allowed
+	index, _ = server.Put([]byte("plain payload"), nil)
+	frame = <-watcher.FrameChan()
+	if !strings.Contains(frame.Message, ErrUnencryptedFrame.Error()) {
+		t.Fatalf("unexpected frame: %+v", frame)
+	}
+	_, err = consumer.Get(ctx, index, 1, 0, false, types.Tags{})
+	if !errors.As(err, &decErr) || !errors.Is(err, ErrUnencryptedFrame) || decErr.Index != index {
+		t.Fatalf("expect unencrypted frame, got %v", err)
+	}
+	migrating := newTestQueueClient(t, server, WithEncryption(newKeys), WithPlaintextAllowed())
+	got, err = migrating.Get(ctx, index, 1, 0, false, types.Tags{})
+	assertNoError(t, err)
+	assertEqual(t, string(got[0].Data), "plain payload")
+}
+
+func TestEncryptionCompression(t *testing.T) {
+	keys, err := NewStaticKeyProvider("k1", map[string][]byte{"k1": bytes.Repeat([]byte{1}, 32)})
+	assertNoError(t, err)
+	server := queuetest.NewServer()
+	defer server.Close()
+	client := newTestQueueClient(t, server, WithEncryption(keys), WithCompression(CompressionZstd, 16))
+	ctx := context.Background()
+
+	payload := strings.Repeat("compressible payload ", 100)
+	index, _, err := client.Put(ctx, []byte(payload), types.Tags{"k": "v"})
+	assertNoError(t, err)
+	_, _, err = client.Put(ctx, []byte("short"), nil)
+	assertNoError(t, err)
+	// the plaintext is compressed inside the envelope, the ciphertext is sent as is
+	frames := server.Frames()
+	assertEqual(t, frames[0].Tags[EncryptionEncodingTag], CompressionZstd)
+	if len(frames[0].Data) >= len(payload)/4 {
+		t.Fatalf("payload of %d bytes is not compressed before encryption: %d bytes", len(payload), len(frames[0].Data))
+	}
+	if _, ok := frames[1].Tags[EncryptionEncodingTag]; ok {
+		t.Fatalf("payload below the threshold should not be compressed: %v", frames[1].Tags)
+	}
+
+	got, err := client.Get(ctx, index, 2, 0, false, types.Tags{})
+	assertNoError(t, err)
+	assertEqual(t, string(got[0].Data), payload)
+	assertEqual(t, got[0].Tags.String(), "tags[k=v requestId=req-1]")
+	assertEqual(t, string(got[1].Data), "short")
+
+	// the encoding is authenticated with the ciphertext
+	tampered := server.Frames()[0]
+	tags := types.Tags{}
+	for k, v := range tampered.Tags {
+		tags[k] = v
+	}
+	tags[EncryptionEncodingTag] = CompressionGzip
+	index, _ = server.Put(tampered.Data, tags)
+	_, err = client.Get(ctx, index, 1, 0, false, types.Tags{})
+	if !strings.Contains(fmt.Sprint(err), "message authentication failed") {
+		t.Fatalf("expect authentication failure, got %v", err)
+	}
+}
+
+func TestStaticKeyProvider(t *testing.T) {
+	_, err := NewStaticKeyProvider("missing", map[string][]byte{"k1": make([]byte, 16)})
+	if !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expect ErrUnknownKey, got %v", err)
+	}
+	_, err = NewStaticKeyProvider("k1", map[string][]byte{"k1": make([]byte, 10)})
+	if err == nil {
+		t.Fatalf("expect invalid key size")
+	}
+}
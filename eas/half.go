@@ -0,0 +1,86 @@
+package eas
+
+import "math"
+
+// float32ToHalf converts a float32 to the bits of IEEE 754 half precision float, rounding to nearest even.
+func float32ToHalf(f float32) uint16 {
+	bits := math.Float32bits(f)
+	sign := uint16(bits>>16) & 0x8000
+	exp := int32(bits>>23) & 0xff
+	mant := bits & 0x7fffff
+
+	switch {
+	case exp == 0xff:
+		// Inf or NaN, keep NaN quiet
+		if mant != 0 {
+			return sign | 0x7e00
+		}
+		return sign | 0x7c00
+	case exp-127+15 >= 0x1f:
+		// overflow to Inf
+		return sign | 0x7c00
+	case exp-127+15 <= 0:
+		// subnormal half or zero
+		if exp-127+15 < -10 {
+			return sign
+		}
+		mant |= 0x800000
+		shift := uint32(14 - (exp - 127 + 15))
+		half := mant >> shift
+		rem := mant & (1<<shift - 1)
+		halfway := uint32(1) << (shift - 1)
+		if rem > halfway || (rem == halfway && half&1 == 1) {
+			half++
+		}
+		return sign | uint16(half)
+	default:
+		half := uint32(exp-127+15)<<10 | mant>>13
+		rem := mant & 0x1fff
+		if rem > 0x1000 || (rem == 0x1000 && half&1 == 1) {
+			// carry into exponent is intended, it rounds up to the next binade or Inf
+			half++
+		}
+		return sign | uint16(half)
+	}
+}
+
+// halfToFloat32 converts the bits of IEEE 754 half precision float to float32.
+func halfToFloat32(h uint16) float32 {
+	sign := uint32(h&0x8000) << 16
+	exp := uint32(h>>10) & 0x1f
+	mant := uint32(h & 0x3ff)
+
+	switch {
+	case exp == 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | mant<<13)
+	case exp == 0:
+		if mant == 0 {
+			return math.Float32frombits(sign)
+		}
+		// normalize the subnormal half
+		exp = 127 - 15 + 1
+		for mant&0x400 == 0 {
+			mant <<= 1
+			exp--
+		}
+		return math.Float32frombits(sign | exp<<23 | (mant&0x3ff)<<13)
+	default:
+		return math.Float32frombits(sign | (exp+127-15)<<23 | mant<<13)
+	}
+}
+
+// float32ToBFloat16 converts a float32 to the bits of bfloat16, rounding to nearest even.
+func float32ToBFloat16(f float32) uint16 {
+	bits := math.Float32bits(f)
+	if bits&0x7f800000 == 0x7f800000 && bits&0x7fffff != 0 {
+		// keep NaN quiet instead of rounding it to Inf
+		return uint16(bits>>16) | 0x40
+	}
+	bits += 0x7fff + (bits>>16)&1
+	return uint16(bits >> 16)
+}
+
+// bfloat16ToFloat32 converts the bits of bfloat16 to float32.
+func bfloat16ToFloat32(b uint16) float32 {
+	return math.Float32frombits(uint32(b) << 16)
+}
@@ -0,0 +1,422 @@
+// Package queuetest provides an in-memory queue service speaking the HTTP protocol of QueueClient,
+// so that queue features can be tested without a real EAS queue service. Watch is served through
+// the streaming HTTP protocol only, clients must disable WebsocketWatch.
+package queuetest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pai-eas/eas-golang-sdk/eas/types"
+)
+
+// Headers identifying users, published in the attributes of Server.
+const (
+	UserHeader     = "X-Eas-Queueservice-User"
+	GroupHeader    = "X-Eas-Queueservice-Group"
+	PriorityHeader = "X-Eas-Queueservice-Priority"
+	requestIdTag   = "requestId"
+	requestHeader  = "X-Eas-Queueservice-Request-Id"
+)
+
+// Negative records a call of QueueClient.Negative.
+type Negative struct {
+	Indexes []uint64
+	Code    string
+	Reason  string
+}
+
+type entry struct {
+	frame     types.DataFrame
+	delivered bool
+}
+
+// Server is a fake queue service. Each frame is delivered to one of the watchers, frames are redelivered
+// after Negative, and removed once they are committed or deleted.
+type Server struct {
+	*httptest.Server
+
+	// Attributes are returned to clients, extra attributes can be set before the first request.
+	Attributes types.Attributes
+
+	mu        sync.Mutex
+	changed   chan struct{}
+	next      uint64
+	entries   []*entry
+	committed []uint64
+	negatives []Negative
+	puts      int
+}
+
+// NewServer starts a fake queue service, which should be closed by the caller.
+func NewServer() *Server {
+	s := &Server{
+		Attributes: types.Attributes{
+			types.Name:                "queuetest",
+			types.UserIdentifyHeader:  UserHeader,
+			types.GroupIdentifyHeader: GroupHeader,
+			types.PriorityHeader:      PriorityHeader,
+		},
+		changed: make(chan struct{}),
+		next:    1,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Put puts a frame into queue directly, the requestId tag is generated if absent.
+func (s *Server) Put(data []byte, tags types.Tags) (uint64, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.put(data, tags)
+}
+
+func (s *Server) put(data []byte, tags types.Tags) (uint64, string) {
+	frame := types.DataFrame{Index: types.FromUint64(s.next), Data: data, Tags: types.Tags{}}
+	for key, val := range tags {
+		frame.Tags[key] = val
+	}
+	if _, ok := frame.Tags[requestIdTag]; !ok {
+		frame.Tags[requestIdTag] = "req-" + strconv.FormatUint(s.next, 10)
+	}
+	s.next++
+	s.puts++
+	s.entries = append(s.entries, &entry{frame: frame})
+	s.notify()
+	return frame.Index.Uint64(), frame.Tags[requestIdTag]
+}
+
+// Frames returns the frames remaining in queue.
+func (s *Server) Frames() []types.DataFrame {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	frames := make([]types.DataFrame, 0, len(s.entries))
+	for _, e := range s.entries {
+		frames = append(frames, e.frame)
+	}
+	return frames
+}
+
+// Committed returns the committed indexes in order.
+func (s *Server) Committed() []uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]uint64{}, s.committed...)
+}
+
+// Negatives returns the calls of Negative in order.
+func (s *Server) Negatives() []Negative {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Negative{}, s.negatives...)
+}
+
+// Puts returns the number of frames put into queue.
+func (s *Server) Puts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.puts
+}
+
+// WaitFor polls until cond returns true, it returns false after timeout.
+func WaitFor(timeout time.Duration, cond func() bool) bool {
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return true
+}
+
+// notify wakes up watchers, s.mu must be held.
+func (s *Server) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func (s *Server) remove(indexes []uint64) {
+	set := map[uint64]bool{}
+	for _, index := range indexes {
+		set[index] = true
+	}
+	entries := s.entries[:0]
+	for _, e := range s.entries {
+		if !set[e.frame.Index.Uint64()] {
+			entries = append(entries, e)
+		}
+	}
+	s.entries = entries
+}
+
+func parseIndexes(value string) ([]uint64, error) {
+	var indexes []uint64
+	for _, item := range strings.Split(value, ",") {
+		if item == "" {
+			continue
+		}
+		index, err := strconv.ParseUint(item, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		indexes = append(indexes, index)
+	}
+	return indexes, nil
+}
+
+// userTags returns the query parameters which are not reserved as tags.
+func userTags(query url.Values) types.Tags {
+	tags := types.Tags{}
+	for key := range query {
+		if !strings.HasPrefix(key, "_") {
+			tags[key] = query.Get(key)
+		}
+	}
+	return tags
+}
+
+// mediaType returns the media type accepted by the request, protobuf by default.
+func mediaType(r *http.Request) string {
+	if accept := r.Header.Get("Accept"); len(accept) > 0 {
+		return accept
+	}
+	return types.ContentTypeProtobuf
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("_attrs_") == "true" {
+		codec, err := types.AttributesCodecFor(mediaType(r))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotAcceptable)
+			return
+		}
+		s.mu.Lock()
+		buf := &bytes.Buffer{}
+		err = codec.Encode(s.Attributes, buf)
+		s.mu.Unlock()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Write(buf.Bytes())
+		return
+	}
+	if r.Header.Get(UserHeader) == "" {
+		http.Error(w, "user identity is required", http.StatusUnauthorized)
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		s.servePut(w, r)
+	case http.MethodGet:
+		if query.Get("_watch_") == "true" {
+			s.serveWatch(w, r)
+		} else {
+			s.serveGet(w, r)
+		}
+	case http.MethodPut:
+		s.serveCommit(w, r)
+	case http.MethodDelete:
+		s.serveDelete(w, r)
+	default:
+		http.Error(w, "unsupported method", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) servePut(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if limit, ok := s.Attributes[types.MaxPayloadBytes]; ok {
+		if max, _ := strconv.Atoi(limit); max > 0 && len(data) > max {
+			http.Error(w, "payload too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+	}
+	s.mu.Lock()
+	index, requestId := s.put(data, userTags(r.URL.Query()))
+	s.mu.Unlock()
+	w.Header().Set(requestHeader, requestId)
+	w.Write([]byte(strconv.FormatUint(index, 10)))
+}
+
+func matchTags(frame types.DataFrame, tags types.Tags) bool {
+	for key, val := range tags {
+		if frame.Tags[key] != val {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Server) serveGet(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	index, _ := strconv.ParseUint(query.Get("_index_"), 10, 64)
+	length, _ := strconv.Atoi(query.Get("_length_"))
+	tags := userTags(query)
+	s.mu.Lock()
+	var frames []types.DataFrame
+	var indexes []uint64
+	for _, e := range s.entries {
+		if len(frames) >= length {
+			break
+		}
+		if e.frame.Index.Uint64() >= index && matchTags(e.frame, tags) {
+			frames = append(frames, e.frame)
+			indexes = append(indexes, e.frame.Index.Uint64())
+		}
+	}
+	if query.Get("_auto_delete_") == "true" {
+		s.remove(indexes)
+	}
+	s.mu.Unlock()
+	codec, err := types.DataFrameCodecFor(mediaType(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotAcceptable)
+		return
+	}
+	buf := &bytes.Buffer{}
+	if err := codec.EncodeList(frames, buf); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(buf.Bytes())
+}
+
+// nextFrame returns the first undelivered frame matching the watch, or the channel closed on changes.
+func (s *Server) nextFrame(index uint64, tags types.Tags, autoCommit bool) (*types.DataFrame, <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.entries {
+		if e.delivered || e.frame.Index.Uint64() < index || !matchTags(e.frame, tags) {
+			continue
+		}
+		e.delivered = true
+		frame := e.frame
+		if autoCommit {
+			s.committed = append(s.committed, frame.Index.Uint64())
+			s.remove([]uint64{frame.Index.Uint64()})
+		}
+		return &frame, nil
+	}
+	return nil, s.changed
+}
+
+func (s *Server) serveWatch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	index, _ := strconv.ParseUint(query.Get("_index_"), 10, 64)
+	autoCommit := query.Get("_auto_commit_") == "true"
+	tags := userTags(query)
+	codec, err := types.DataFrameCodecFor(mediaType(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotAcceptable)
+		return
+	}
+	flusher, _ := w.(http.Flusher)
+	w.WriteHeader(http.StatusOK)
+	if flusher != nil {
+		flusher.Flush()
+	}
+	writer := types.NewLengthDelimitedFrameWriter(w)
+	for {
+		frame, changed := s.nextFrame(index, tags, autoCommit)
+		if frame == nil {
+			select {
+			case <-changed:
+				continue
+			case <-r.Context().Done():
+				return
+			}
+		}
+		buf := &bytes.Buffer{}
+		if err := codec.Encode(*frame, buf); err != nil {
+			return
+		}
+		if _, err := writer.Write(buf.Bytes()); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+func (s *Server) serveCommit(w http.ResponseWriter, r *http.Request) {
+	indexes, err := parseIndexes(r.URL.Query().Get("_indexes_"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.URL.Query().Get("_negative_") == "true" {
+		r.ParseForm()
+		s.negatives = append(s.negatives, Negative{Indexes: indexes, Code: r.PostForm.Get("_code_"), Reason: r.PostForm.Get("_reason_")})
+		set := map[uint64]bool{}
+		for _, index := range indexes {
+			set[index] = true
+		}
+		// negatively acknowledged frames are delivered again
+		for _, e := range s.entries {
+			if set[e.frame.Index.Uint64()] {
+				e.delivered = false
+			}
+		}
+		s.notify()
+		return
+	}
+	s.committed = append(s.committed, indexes...)
+	s.remove(indexes)
+}
+
+func (s *Server) serveDelete(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if query.Get("_trunc_") == "true" {
+		index, _ := strconv.ParseUint(query.Get("_index_"), 10, 64)
+		var indexes []uint64
+		for _, e := range s.entries {
+			if e.frame.Index.Uint64() < index {
+				indexes = append(indexes, e.frame.Index.Uint64())
+			}
+		}
+		s.remove(indexes)
+		return
+	}
+	indexes, err := parseIndexes(query.Get("_indexes_"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.remove(indexes)
+}
+
+// SortIndexes sorts indexes in place and returns them, for comparing indexes committed concurrently.
+func SortIndexes(indexes []uint64) []uint64 {
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+	return indexes
+}
+
+// String describes the frames remaining in queue, for test failures.
+func (s *Server) String() string {
+	frames := s.Frames()
+	parts := make([]string, len(frames))
+	for i, frame := range frames {
+		parts[i] = fmt.Sprintf("%d:%q%v", frame.Index.Uint64(), frame.Data, frame.Tags)
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
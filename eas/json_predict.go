@@ -0,0 +1,60 @@
+package eas
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// JSONCodec marshals requests and unmarshals responses of JSONPredict, it can be replaced by
+// SetJSONCodec to use a faster JSON library with the same API as encoding/json.
+type JSONCodec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+type stdJSONCodec struct{}
+
+func (stdJSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (stdJSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// DefaultJSONCodec is the JSONCodec of encoding/json, used unless another one is set.
+var DefaultJSONCodec JSONCodec = stdJSONCodec{}
+
+// SetJSONCodec sets the codec used by JSONPredict and the chat client, encoding/json by default
+func (p *PredictClient) SetJSONCodec(codec JSONCodec) {
+	p.jsonCodec = codec
+}
+
+func (p *PredictClient) codec() JSONCodec {
+	if p.jsonCodec == nil {
+		return DefaultJSONCodec
+	}
+	return p.jsonCodec
+}
+
+// JSONPredict marshals in as the JSON request body, sends it with the JSON content type and unmarshals
+// the response into out, which is skipped if out is nil. A failed request returns *PredictError whose
+// Code is the HTTP status and Message is the error body returned by the service.
+func (p *PredictClient) JSONPredict(ctx context.Context, in interface{}, out interface{}, opts ...PredictOption) error {
+	data, err := p.codec().Marshal(in)
+	if err != nil {
+		return NewPredictError(ErrorCodeCreateRequest, "", err.Error())
+	}
+	opts = append([]PredictOption{WithContentType("application/json"), WithAccept("application/json")}, opts...)
+	body, err := p.BytesPredictWithContext(ctx, data, opts...)
+	if err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	if err := p.codec().Unmarshal(body, out); err != nil {
+		return NewPredictError(ErrorCodeReadResponse, "", err.Error())
+	}
+	return nil
+}
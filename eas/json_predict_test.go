@@ -0,0 +1,59 @@
+package eas
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type countingCodec struct {
+	marshaled, unmarshaled int
+}
+
+func (c *countingCodec) Marshal(v interface{}) ([]byte, error) {
+	c.marshaled++
+	return json.Marshal(v)
+}
+
+func (c *countingCodec) Unmarshal(data []byte, v interface{}) error {
+	c.unmarshaled++
+	return json.Unmarshal(data, v)
+}
+
+func TestJSONPredict(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected content type: %s", r.Header.Get("Content-Type"))
+		}
+		rows := []map[string]float64{}
+		json.NewDecoder(r.Body).Decode(&rows)
+		if len(rows) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "empty input"}`))
+			return
+		}
+		w.Write([]byte(`[{"p_0": 0.25, "p_1": 0.75}]`))
+	}))
+	defer server.Close()
+	client := NewPredictClient(server.Listener.Addr().String(), "pmml")
+	client.SetRetryCount(0)
+	codec := &countingCodec{}
+	client.SetJSONCodec(codec)
+	client.Init()
+
+	var out []map[string]float64
+	err := client.JSONPredict(context.Background(), []map[string]float64{{"fea1": 1, "fea2": 2}}, &out)
+	assertNoError(t, err)
+	assertEqual(t, out[0]["p_1"], 0.75)
+	assertEqual(t, codec.marshaled, 1)
+	assertEqual(t, codec.unmarshaled, 1)
+
+	err = client.JSONPredict(context.Background(), []map[string]float64{}, &out)
+	predictErr, ok := err.(*PredictError)
+	if !ok || predictErr.Code != http.StatusBadRequest || !strings.Contains(predictErr.Message, "empty input") {
+		t.Fatalf("expect error body of service, got %v", err)
+	}
+}
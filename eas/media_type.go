@@ -0,0 +1,30 @@
+package eas
+
+import (
+	"fmt"
+
+	"github.com/pai-eas/eas-golang-sdk/eas/types"
+)
+
+// mediaTypeCodecs returns the codecs of the first preferred media type with a registered DataFrameCodec,
+// protobuf if there is no preference. Attributes fall back to protobuf for the media types without
+// AttributesCodec.
+func mediaTypeCodecs(mediaTypes []string) (types.DataFrameCodec, types.AttributesCodec, error) {
+	if len(mediaTypes) == 0 {
+		mediaTypes = []string{types.ContentTypeProtobuf}
+	}
+	for _, mediaType := range mediaTypes {
+		if dCodec, err := types.DataFrameCodecFor(mediaType); err == nil {
+			return dCodec, attributesCodecFor(mediaType), nil
+		}
+	}
+	return nil, nil, fmt.Errorf("no codec of media types %v is registered: %w", mediaTypes, types.ErrUnsupportedMediaType)
+}
+
+func attributesCodecFor(mediaType string) types.AttributesCodec {
+	if codec, err := types.AttributesCodecFor(mediaType); err == nil {
+		return codec
+	}
+	codec, _ := types.AttributesCodecFor(types.ContentTypeProtobuf)
+	return codec
+}
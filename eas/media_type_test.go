@@ -0,0 +1,70 @@
+package eas
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pai-eas/eas-golang-sdk/eas/internal/queuetest"
+	"github.com/pai-eas/eas-golang-sdk/eas/types"
+)
+
+const testMediaType = "application/x-test"
+
+// testCodec is the JSON codec registered under another media type.
+type testCodec struct {
+	types.DataFrameCodec
+}
+
+func (c *testCodec) MediaType() string {
+	return testMediaType
+}
+
+func init() {
+	types.RegisterDataFrameCodec(testMediaType, func() types.DataFrameCodec {
+		codec, _ := types.DataFrameCodecFor(types.ContentTypeJSON)
+		return &testCodec{codec}
+	})
+}
+
+func TestMediaTypePreference(t *testing.T) {
+	server := queuetest.NewServer()
+	defer server.Close()
+	ctx := context.Background()
+	index, _ := server.Put([]byte("data"), types.Tags{"k": "v"})
+	get := func(client *QueueClient) {
+		t.Helper()
+		frames, err := client.Get(ctx, index, 1, 0, false, types.Tags{})
+		assertNoError(t, err)
+		assertEqual(t, string(frames[0].Data), "data")
+		assertEqual(t, frames[0].Tags.Get("k"), "v")
+	}
+
+	client := newTestQueueClient(t, server)
+	get(client)
+	assertEqual(t, client.DCodec.MediaType(), types.ContentTypeProtobuf)
+	assertEqual(t, client.ACodec.MediaType(), types.ContentTypeProtobuf)
+
+	// the first media type with a registered codec is used
+	client = newTestQueueClient(t, server, WithMediaType("application/unknown", types.ContentTypeJSON, types.ContentTypeProtobuf))
+	get(client)
+	assertEqual(t, client.DCodec.MediaType(), types.ContentTypeJSON)
+	assertEqual(t, client.ACodec.MediaType(), types.ContentTypeJSON)
+
+	// attributes fall back to protobuf without the codec
+	client = newTestQueueClient(t, server, WithMediaType(testMediaType))
+	get(client)
+	assertEqual(t, client.DCodec.MediaType(), testMediaType)
+	assertEqual(t, client.ACodec.MediaType(), types.ContentTypeProtobuf)
+
+	// the codecs replaced by the user are used
+	client = newTestQueueClient(t, server)
+	client.DCodec, _ = types.DataFrameCodecFor(testMediaType)
+	get(client)
+	assertEqual(t, client.DCodec.MediaType(), testMediaType)
+
+	_, err := NewQueueClient(server.URL, "", "token", WithMediaType("application/unknown", types.ContentTypeFlatbuffer))
+	if !errors.Is(err, types.ErrUnsupportedMediaType) {
+		t.Fatalf("expect ErrUnsupportedMediaType, got %v", err)
+	}
+}
@@ -0,0 +1,500 @@
+package eas
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pai-eas/eas-golang-sdk/eas/types/tf_predict_protos"
+)
+
+const npyMagic = "\x93NUMPY"
+
+// Limits of the NPY files read, the sizes in headers are checked against them before allocating, so
+// malformed or malicious files fail instead of exhausting memory.
+const (
+	npyMaxHeaderLen = 1 << 20
+	npyMaxDataBytes = 1<<31 - 1
+)
+
+// npyDescrs maps dtypes to the descr of NPY format when they are written, quantized types are
+// written as their underlying integers since numpy has no such types, and bfloat16 is written as
+// the raw bits in uint16 since numpy can not load it without the ml_dtypes package, e.g.
+// numpy.load(path).view(ml_dtypes.bfloat16) gets it back.
+var npyDescrs = map[tf_predict_protos.ArrayDataType]string{
+	TfType_DT_FLOAT:      "<f4",
+	TfType_DT_DOUBLE:     "<f8",
+	TfType_DT_HALF:       "<f2",
+	TfType_DT_BFLOAT16:   "<u2",
+	TfType_DT_INT8:       "|i1",
+	TfType_DT_QINT8:      "|i1",
+	TfType_DT_UINT8:      "|u1",
+	TfType_DT_QUINT8:     "|u1",
+	TfType_DT_INT16:      "<i2",
+	TfType_DT_QINT16:     "<i2",
+	TfType_DT_UINT16:     "<u2",
+	TfType_DT_QUINT16:    "<u2",
+	TfType_DT_INT32:      "<i4",
+	TfType_DT_QINT32:     "<i4",
+	TfType_DT_INT64:      "<i8",
+	TfType_DT_BOOL:       "|b1",
+	TfType_DT_COMPLEX64:  "<c8",
+	TfType_DT_COMPLEX128: "<c16",
+}
+
+// npyTypes maps the type code and item size of descr to dtypes when they are read.
+var npyTypes = map[string]tf_predict_protos.ArrayDataType{
+	"f4":  TfType_DT_FLOAT,
+	"f8":  TfType_DT_DOUBLE,
+	"f2":  TfType_DT_HALF,
+	"V2":  TfType_DT_BFLOAT16,
+	"i1":  TfType_DT_INT8,
+	"u1":  TfType_DT_UINT8,
+	"i2":  TfType_DT_INT16,
+	"u2":  TfType_DT_UINT16,
+	"i4":  TfType_DT_INT32,
+	"i8":  TfType_DT_INT64,
+	"b1":  TfType_DT_BOOL,
+	"c8":  TfType_DT_COMPLEX64,
+	"c16": TfType_DT_COMPLEX128,
+}
+
+var (
+	npyDescrPattern   = regexp.MustCompile(`'descr'\s*:\s*'([^']*)'`)
+	npyFortranPattern = regexp.MustCompile(`'fortran_order'\s*:\s*(True|False)`)
+	npyShapePattern   = regexp.MustCompile(`'shape'\s*:\s*\(([^)]*)\)`)
+)
+
+// ReadNpy reads a tensor in NPY format of version 1.0, 2.0 or 3.0. Both byte orders and both C and
+// Fortran orders are supported, the tensor is always in C order. Fixed-length byte strings 'S' and
+// unicode strings 'U' are read as DT_STRING.
+func ReadNpy(r io.Reader) (*Tensor, error) {
+	preamble := make([]byte, len(npyMagic)+2)
+	if _, err := io.ReadFull(r, preamble); err != nil {
+		return nil, fmt.Errorf("read npy magic: %v", err)
+	}
+	if string(preamble[:len(npyMagic)]) != npyMagic {
+		return nil, fmt.Errorf("not a npy file")
+	}
+	var headerLen int
+	switch major := preamble[len(npyMagic)]; major {
+	case 1:
+		var n uint16
+		if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+			return nil, err
+		}
+		headerLen = int(n)
+	case 2, 3:
+		var n uint32
+		if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+			return nil, err
+		}
+		headerLen = int(n)
+	default:
+		return nil, fmt.Errorf("unsupported npy version %d", major)
+	}
+	if headerLen > npyMaxHeaderLen {
+		return nil, fmt.Errorf("npy header of %d bytes exceeds the limit of %d bytes", headerLen, npyMaxHeaderLen)
+	}
+	header := make([]byte, headerLen)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("read npy header: %v", err)
+	}
+
+	descr := npyDescrPattern.FindSubmatch(header)
+	fortran := npyFortranPattern.FindSubmatch(header)
+	shapeMatch := npyShapePattern.FindSubmatch(header)
+	if descr == nil || fortran == nil || shapeMatch == nil {
+		return nil, fmt.Errorf("invalid npy header: %s", header)
+	}
+	var shape []int64
+	for _, dim := range strings.Split(string(shapeMatch[1]), ",") {
+		if dim = strings.TrimSpace(dim); dim == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(dim, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid npy shape: %s", shapeMatch[1])
+		}
+		if n > 0 && npyElementLimit(shape) < n {
+			return nil, fmt.Errorf("npy shape %s exceeds the limit of %d bytes", shapeMatch[1], npyMaxDataBytes)
+		}
+		shape = append(shape, n)
+	}
+	count, err := shapeSize(shape)
+	if err != nil {
+		return nil, err
+	}
+
+	tensor, err := readNpyData(r, string(descr[1]), shape, count)
+	if err != nil {
+		return nil, err
+	}
+	if string(fortran[1]) == "True" && len(shape) > 1 {
+		tensor.data = fortranToC(tensor.data, shape)
+	}
+	return tensor, nil
+}
+
+// npyElementLimit returns the max length of the next dim of shape, with which every element of the
+// array takes at least a byte and all of them fit into npyMaxDataBytes.
+func npyElementLimit(shape []int64) int64 {
+	limit := int64(npyMaxDataBytes)
+	for _, dim := range shape {
+		if dim > 0 {
+			limit /= dim
+		}
+	}
+	return limit
+}
+
+// readNpyBytes reads n bytes of data, the buffer grows as data arrives rather than being allocated
+// up front, so truncated files fail before allocating the size claimed by their headers.
+func readNpyBytes(r io.Reader, n int) ([]byte, error) {
+	if n > npyMaxDataBytes {
+		return nil, fmt.Errorf("npy data of %d bytes exceeds the limit of %d bytes", n, npyMaxDataBytes)
+	}
+	initial := n
+	if initial > 1<<20 {
+		initial = 1 << 20
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, initial))
+	if _, err := io.CopyN(buf, r, int64(n)); err != nil {
+		return nil, fmt.Errorf("read npy data: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func readNpyData(r io.Reader, descr string, shape []int64, count int) (*Tensor, error) {
+	if descr == "bfloat16" {
+		descr = "<V2"
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	switch {
+	case strings.HasPrefix(descr, ">"):
+		order = binary.BigEndian
+		descr = descr[1:]
+	case strings.HasPrefix(descr, "<"), strings.HasPrefix(descr, "|"), strings.HasPrefix(descr, "="):
+		descr = descr[1:]
+	}
+
+	if strings.HasPrefix(descr, "S") || strings.HasPrefix(descr, "U") {
+		size, err := strconv.Atoi(descr[1:])
+		if err != nil {
+			return nil, fmt.Errorf("unsupported npy descr %s", descr)
+		}
+		data, err := readNpyStrings(r, order, descr[0] == 'U', size, count)
+		if err != nil {
+			return nil, err
+		}
+		return NewTensor(TfType_DT_STRING, shape, data)
+	}
+
+	dtype, ok := npyTypes[descr]
+	if !ok {
+		return nil, fmt.Errorf("unsupported npy descr %s", descr)
+	}
+	itemSize := 2
+	if dtype != TfType_DT_HALF && dtype != TfType_DT_BFLOAT16 {
+		itemSize = int(tensorElemTypes[dtype].Size())
+	}
+	if count > npyMaxDataBytes/itemSize {
+		return nil, fmt.Errorf("npy data of %d elements exceeds the limit of %d bytes", count, npyMaxDataBytes)
+	}
+	raw, err := readNpyBytes(r, count*itemSize)
+	if err != nil {
+		return nil, err
+	}
+	var data interface{}
+	switch dtype {
+	case TfType_DT_HALF, TfType_DT_BFLOAT16:
+		bits := make([]uint16, count)
+		if err := binary.Read(bytes.NewReader(raw), order, bits); err != nil {
+			return nil, fmt.Errorf("read npy data: %v", err)
+		}
+		values := make([]float32, count)
+		for i, b := range bits {
+			if dtype == TfType_DT_HALF {
+				values[i] = halfToFloat32(b)
+			} else {
+				values[i] = bfloat16ToFloat32(b)
+			}
+		}
+		data = values
+	default:
+		value := reflect.MakeSlice(reflect.SliceOf(tensorElemTypes[dtype]), count, count)
+		if err := binary.Read(bytes.NewReader(raw), order, value.Interface()); err != nil {
+			return nil, fmt.Errorf("read npy data: %v", err)
+		}
+		data = value.Interface()
+	}
+	return NewTensor(dtype, shape, data)
+}
+
+func readNpyStrings(r io.Reader, order binary.ByteOrder, unicode bool, size int, count int) ([][]byte, error) {
+	if size < 0 || size > npyMaxDataBytes/4 {
+		return nil, fmt.Errorf("invalid npy string size %d", size)
+	}
+	itemSize := size
+	if unicode {
+		itemSize *= 4
+	}
+	if itemSize > 0 && count > npyMaxDataBytes/itemSize {
+		return nil, fmt.Errorf("npy data of %d strings exceeds the limit of %d bytes", count, npyMaxDataBytes)
+	}
+	buf, err := readNpyBytes(r, itemSize*count)
+	if err != nil {
+		return nil, err
+	}
+	data := make([][]byte, count)
+	for i := range data {
+		item := buf[i*itemSize : (i+1)*itemSize]
+		if !unicode {
+			data[i] = bytes.TrimRight(item, "\x00")
+			continue
+		}
+		var s []byte
+		for j := 0; j < size; j++ {
+			c := order.Uint32(item[j*4:])
+			if c == 0 {
+				break
+			}
+			s = append(s, string(rune(c))...)
+		}
+		data[i] = s
+	}
+	return data, nil
+}
+
+// fortranToC reorders the data of column-major layout into row-major layout.
+func fortranToC(data reflect.Value, shape []int64) reflect.Value {
+	ret := reflect.MakeSlice(data.Type(), data.Len(), data.Len())
+	index := make([]int64, len(shape))
+	for c := 0; c < data.Len(); c++ {
+		f, stride := int64(0), int64(1)
+		for k := range shape {
+			f += index[k] * stride
+			stride *= shape[k]
+		}
+		ret.Index(c).Set(data.Index(int(f)))
+		// advance the multi-index in row-major order
+		for k := len(shape) - 1; k >= 0; k-- {
+			index[k]++
+			if index[k] < shape[k] {
+				break
+			}
+			index[k] = 0
+		}
+	}
+	return ret
+}
+
+// WriteNpy writes the tensor in NPY format in C order and little-endian. Strings are written as
+// fixed-length byte strings 'S' padded to the longest one.
+func WriteNpy(w io.Writer, tensor *Tensor) error {
+	descr, ok := npyDescrs[tensor.dtype]
+	var strs [][]byte
+	if tensor.dtype == TfType_DT_STRING {
+		strs = tensor.Data().([][]byte)
+		size := 1
+		for _, s := range strs {
+			if len(s) > size {
+				size = len(s)
+			}
+		}
+		descr, ok = "|S"+strconv.Itoa(size), true
+	}
+	if !ok {
+		return fmt.Errorf("unsupported dtype %v in npy format", tensor.dtype)
+	}
+
+	dims := make([]string, len(tensor.shape))
+	for i, dim := range tensor.shape {
+		dims[i] = strconv.FormatInt(dim, 10)
+	}
+	shape := strings.Join(dims, ", ")
+	if len(dims) == 1 {
+		shape += ","
+	}
+	header := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': (%s), }", descr, shape)
+	// the header is padded with spaces and a newline to align the data by 64 bytes
+	version, lenSize := byte(1), 2
+	if len(header)+len(npyMagic)+2+2+1 > 65535 {
+		version, lenSize = 2, 4
+	}
+	prefix := len(npyMagic) + 2 + lenSize
+	padding := 64 - (prefix+len(header)+1)%64
+	if padding == 64 {
+		padding = 0
+	}
+	header += strings.Repeat(" ", padding) + "\n"
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(npyMagic)
+	buf.Write([]byte{version, 0})
+	if version == 1 {
+		binary.Write(buf, binary.LittleEndian, uint16(len(header)))
+	} else {
+		binary.Write(buf, binary.LittleEndian, uint32(len(header)))
+	}
+	buf.WriteString(header)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+
+	switch tensor.dtype {
+	case TfType_DT_STRING:
+		size, _ := strconv.Atoi(descr[2:])
+		item := make([]byte, size)
+		for _, s := range strs {
+			copy(item, s)
+			for i := len(s); i < size; i++ {
+				item[i] = 0
+			}
+			if _, err := w.Write(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case TfType_DT_HALF, TfType_DT_BFLOAT16:
+		values := tensor.Data().([]float32)
+		bits := make([]uint16, len(values))
+		for i, v := range values {
+			if tensor.dtype == TfType_DT_HALF {
+				bits[i] = float32ToHalf(v)
+			} else {
+				bits[i] = float32ToBFloat16(v)
+			}
+		}
+		return binary.Write(w, binary.LittleEndian, bits)
+	default:
+		return binary.Write(w, binary.LittleEndian, tensor.Data())
+	}
+}
+
+// ReadNpz reads the arrays in a NPZ archive written by numpy.savez or numpy.savez_compressed, arrays
+// are keyed by their names without the .npy suffix, positional arrays are named arr_0, arr_1 and so on.
+func ReadNpz(r io.ReaderAt, size int64) (map[string]*Tensor, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	tensors := make(map[string]*Tensor, len(archive.File))
+	for _, file := range archive.File {
+		if !strings.HasSuffix(file.Name, ".npy") {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		tensor, err := ReadNpy(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file.Name, err)
+		}
+		tensors[strings.TrimSuffix(file.Name, ".npy")] = tensor
+	}
+	return tensors, nil
+}
+
+// WriteNpz writes the tensors into a NPZ archive like numpy.savez, which can be loaded by numpy.load.
+func WriteNpz(w io.Writer, tensors map[string]*Tensor) error {
+	names := make([]string, 0, len(tensors))
+	for name := range tensors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	archive := zip.NewWriter(w)
+	for _, name := range names {
+		file, err := archive.CreateHeader(&zip.FileHeader{Name: name + ".npy", Method: zip.Store})
+		if err != nil {
+			return err
+		}
+		if err = WriteNpy(file, tensors[name]); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	return archive.Close()
+}
+
+// LoadNpy reads a tensor from the .npy file.
+func LoadNpy(path string) (*Tensor, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadNpy(file)
+}
+
+// SaveNpy writes the tensor into the .npy file.
+func SaveNpy(path string, tensor *Tensor) error {
+	buf := &bytes.Buffer{}
+	if err := WriteNpy(buf, tensor); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// LoadNpz reads the arrays from the .npz file.
+func LoadNpz(path string) (map[string]*Tensor, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ReadNpz(bytes.NewReader(data), int64(len(data)))
+}
+
+// SaveNpz writes the tensors into the .npz file.
+func SaveNpz(path string, tensors map[string]*Tensor) error {
+	buf := &bytes.Buffer{}
+	if err := WriteNpz(buf, tensors); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// AddFeedTensors function adds the tensors keyed by input names as input data for TFRequest,
+// e.g. the arrays read by LoadNpz.
+func (tr *TFRequest) AddFeedTensors(tensors map[string]*Tensor) {
+	for name, tensor := range tensors {
+		tr.AddFeedTensor(name, tensor)
+	}
+}
+
+// GetTensors returns all outputs as tensors keyed by output names, e.g. to be written by SaveNpz.
+func (tresp *TFResponse) GetTensors() (map[string]*Tensor, error) {
+	tensors := make(map[string]*Tensor, len(tresp.Response.Outputs))
+	for name := range tresp.Response.Outputs {
+		tensor, err := tresp.GetTensor(name)
+		if err != nil {
+			return nil, fmt.Errorf("output [%s]: %v", name, err)
+		}
+		tensors[name] = tensor
+	}
+	return tensors, nil
+}
+
+// GetTensors returns all outputs as tensors keyed by arr_0, arr_1 and so on, the names numpy.savez
+// gives to positional arrays.
+func (resp *TorchResponse) GetTensors() (map[string]*Tensor, error) {
+	tensors := make(map[string]*Tensor, len(resp.Response.Outputs))
+	for i := range resp.Response.Outputs {
+		tensor, err := resp.GetTensor(i)
+		if err != nil {
+			return nil, fmt.Errorf("output [%d]: %v", i, err)
+		}
+		tensors["arr_"+strconv.Itoa(i)] = tensor
+	}
+	return tensors, nil
+}
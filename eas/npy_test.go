@@ -0,0 +1,126 @@
+package eas
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func TestNpyRoundTrip(t *testing.T) {
+	for _, value := range []interface{}{
+		[][]float32{{1, 2, 3}, {4, 5, 6}},
+		[]int64{-1, 0, 1},
+		[][]bool{{true}, {false}},
+		[]complex64{complex(1, -1)},
+		[]string{"cat", "horse"},
+		int32(7),
+	} {
+		tensor, err := TensorFromSlice(value)
+		assertNoError(t, err)
+		buf := &bytes.Buffer{}
+		assertNoError(t, WriteNpy(buf, tensor))
+		got, err := ReadNpy(buf)
+		assertNoError(t, err)
+		if !reflect.DeepEqual(got.ToSlice(), value) {
+			t.Fatalf("npy round trip of %v got %v", value, got.ToSlice())
+		}
+	}
+
+	half, err := NewTensor(TfType_DT_HALF, []int64{2}, []float32{0.5, -2})
+	assertNoError(t, err)
+	buf := &bytes.Buffer{}
+	assertNoError(t, WriteNpy(buf, half))
+	got, err := ReadNpy(buf)
+	assertNoError(t, err)
+	assertEqual(t, got.Dtype(), TfType_DT_HALF)
+	if !reflect.DeepEqual(got.Data(), []float32{0.5, -2}) {
+		t.Fatalf("unexpected half data: %v", got.Data())
+	}
+}
+
+func buildNpy(header string, data []byte) []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString(npyMagic)
+	buf.Write([]byte{1, 0})
+	binary.Write(buf, binary.LittleEndian, uint16(len(header)))
+	buf.WriteString(header)
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+func TestReadNpyLayouts(t *testing.T) {
+	// [[1, 2, 3], [4, 5, 6]] stored in Fortran order as big-endian int16
+	data := &bytes.Buffer{}
+	binary.Write(data, binary.BigEndian, []int16{1, 4, 2, 5, 3, 6})
+	tensor, err := ReadNpy(bytes.NewReader(buildNpy(
+		"{'descr': '>i2', 'fortran_order': True, 'shape': (2, 3), }\n", data.Bytes())))
+	assertNoError(t, err)
+	if !reflect.DeepEqual(tensor.ToSlice(), [][]int16{{1, 2, 3}, {4, 5, 6}}) {
+		t.Fatalf("unexpected tensor: %v", tensor.ToSlice())
+	}
+
+	data.Reset()
+	binary.Write(data, binary.LittleEndian, []uint32{'h', 'i', 0, 0x4e2d, 0, 0})
+	tensor, err = ReadNpy(bytes.NewReader(buildNpy(
+		"{'descr': '<U3', 'fortran_order': False, 'shape': (2,), }\n", data.Bytes())))
+	assertNoError(t, err)
+	if !reflect.DeepEqual(tensor.ToSlice(), []string{"hi", "中"}) {
+		t.Fatalf("unexpected strings: %v", tensor.ToSlice())
+	}
+
+	if _, err = ReadNpy(bytes.NewReader(buildNpy(
+		"{'descr': '|O', 'fortran_order': False, 'shape': (1,), }\n", nil))); err == nil {
+		t.Fatal("object array should be rejected")
+	}
+}
+
+func TestNpzRequestResponse(t *testing.T) {
+	images, _ := TensorFromSlice([][]float32{{0, 1}})
+	ids, _ := TensorFromSlice([]int64{42})
+	buf := &bytes.Buffer{}
+	assertNoError(t, WriteNpz(buf, map[string]*Tensor{"images": images, "ids": ids}))
+	tensors, err := ReadNpz(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assertNoError(t, err)
+	assertEqual(t, len(tensors), 2)
+
+	req := TFRequest{}
+	req.AddFeedTensors(tensors)
+	assertNoError(t, req.Validate())
+	resp := TFResponse{}
+	resp.Response.Outputs = req.RequestData.Inputs
+	outputs, err := resp.GetTensors()
+	assertNoError(t, err)
+	if !reflect.DeepEqual(outputs["images"].ToSlice(), [][]float32{{0, 1}}) {
+		t.Fatalf("unexpected output: %v", outputs["images"].ToSlice())
+	}
+}
+
+func TestReadNpyLimits(t *testing.T) {
+	// bfloat16 is written as uint16 bits which numpy loads without ml_dtypes
+	bf16, err := NewTensor(TfType_DT_BFLOAT16, []int64{2}, []float32{1, -2})
+	assertNoError(t, err)
+	buf := &bytes.Buffer{}
+	assertNoError(t, WriteNpy(buf, bf16))
+	got, err := ReadNpy(buf)
+	assertNoError(t, err)
+	if !reflect.DeepEqual(got.ToSlice(), []uint16{0x3f80, 0xc000}) {
+		t.Fatalf("unexpected bfloat16 bits: %v", got.ToSlice())
+	}
+
+	// sizes claimed by headers are checked before allocating
+	huge := &bytes.Buffer{}
+	huge.WriteString(npyMagic)
+	huge.Write([]byte{2, 0})
+	binary.Write(huge, binary.LittleEndian, uint32(1<<31))
+	for _, data := range [][]byte{
+		huge.Bytes(),
+		buildNpy("{'descr': '<f8', 'fortran_order': False, 'shape': (65536, 65536), }\n", nil),
+		buildNpy("{'descr': '<f8', 'fortran_order': False, 'shape': (1000000,), }\n", make([]byte, 8)),
+		buildNpy("{'descr': '<U99999999999', 'fortran_order': False, 'shape': (1,), }\n", nil),
+	} {
+		if _, err = ReadNpy(bytes.NewReader(data)); err == nil {
+			t.Fatalf("expect error reading %q", data)
+		}
+	}
+}
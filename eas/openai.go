@@ -0,0 +1,267 @@
+package eas
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	// ChatCompletionsPath is the sub path of chat completions API of OpenAI compatible services.
+	ChatCompletionsPath = "/v1/chat/completions"
+
+	ChatRoleSystem    = "system"
+	ChatRoleUser      = "user"
+	ChatRoleAssistant = "assistant"
+	ChatRoleTool      = "tool"
+
+	// chatStreamDone is the data of the last event in a chat completions stream.
+	chatStreamDone = "[DONE]"
+)
+
+// ChatMessage is a message in the conversation of chat completions.
+type ChatMessage struct {
+	Role       string     `json:"role"`
+	Content    string     `json:"content"`
+	Name       string     `json:"name,omitempty"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+}
+
+// Tool is a tool the model may call, currently only functions are supported.
+type Tool struct {
+	Type     string             `json:"type"`
+	Function FunctionDefinition `json:"function"`
+}
+
+// FunctionDefinition describes a function, Parameters is the JSON schema of its arguments.
+type FunctionDefinition struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Parameters  interface{} `json:"parameters,omitempty"`
+}
+
+// ToolCall is a call of tool generated by the model. Index is only set in streaming deltas,
+// in which the fields of a call are spread over several chunks.
+type ToolCall struct {
+	Index    *int         `json:"index,omitempty"`
+	ID       string       `json:"id,omitempty"`
+	Type     string       `json:"type,omitempty"`
+	Function FunctionCall `json:"function"`
+}
+
+// FunctionCall is the name and the JSON encoded arguments of a function call.
+type FunctionCall struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+}
+
+// StreamOptions are the options of streaming response.
+type StreamOptions struct {
+	// IncludeUsage requests an extra chunk carrying the token usage of the whole request.
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// ChatCompletionRequest is the request of chat completions API.
+type ChatCompletionRequest struct {
+	Model            string         `json:"model"`
+	Messages         []ChatMessage  `json:"messages"`
+	Tools            []Tool         `json:"tools,omitempty"`
+	ToolChoice       interface{}    `json:"tool_choice,omitempty"`
+	Temperature      *float32       `json:"temperature,omitempty"`
+	TopP             *float32       `json:"top_p,omitempty"`
+	N                int            `json:"n,omitempty"`
+	MaxTokens        int            `json:"max_tokens,omitempty"`
+	Stop             []string       `json:"stop,omitempty"`
+	PresencePenalty  float32        `json:"presence_penalty,omitempty"`
+	FrequencyPenalty float32        `json:"frequency_penalty,omitempty"`
+	Seed             *int           `json:"seed,omitempty"`
+	User             string         `json:"user,omitempty"`
+	Stream           bool           `json:"stream,omitempty"`
+	StreamOptions    *StreamOptions `json:"stream_options,omitempty"`
+}
+
+// Usage is the token usage of a request.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// ChatCompletionChoice is a generated message of chat completions.
+type ChatCompletionChoice struct {
+	Index        int         `json:"index"`
+	Message      ChatMessage `json:"message"`
+	FinishReason string      `json:"finish_reason"`
+}
+
+// ChatCompletionResponse is the response of chat completions API.
+type ChatCompletionResponse struct {
+	ID      string                 `json:"id"`
+	Object  string                 `json:"object"`
+	Created int64                  `json:"created"`
+	Model   string                 `json:"model"`
+	Choices []ChatCompletionChoice `json:"choices"`
+	Usage   *Usage                 `json:"usage,omitempty"`
+}
+
+// ChatCompletionDelta is the increment of a message in streaming response.
+type ChatCompletionDelta struct {
+	Role      string     `json:"role,omitempty"`
+	Content   string     `json:"content,omitempty"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+}
+
+// ChatCompletionChunkChoice is the increment of a choice in streaming response.
+type ChatCompletionChunkChoice struct {
+	Index        int                 `json:"index"`
+	Delta        ChatCompletionDelta `json:"delta"`
+	FinishReason string              `json:"finish_reason,omitempty"`
+}
+
+// ChatCompletionChunk is a chunk of streaming response of chat completions API.
+type ChatCompletionChunk struct {
+	ID      string                      `json:"id"`
+	Object  string                      `json:"object"`
+	Created int64                       `json:"created"`
+	Model   string                      `json:"model"`
+	Choices []ChatCompletionChunkChoice `json:"choices"`
+	Usage   *Usage                      `json:"usage,omitempty"`
+}
+
+// ChatClient is a client of OpenAI compatible chat completions API, served by runtimes like vLLM
+// deployed on EAS. It sends requests through the PredictClient, so that the endpoint discovery,
+// authentication and retrying of PredictClient are reused.
+type ChatClient struct {
+	client *PredictClient
+}
+
+// NewChatClient returns a chat client on top of an initialized PredictClient.
+func NewChatClient(client *PredictClient) *ChatClient {
+	return &ChatClient{client: client}
+}
+
+// CreateChatCompletion sends the request and returns the whole generated response.
+func (c *ChatClient) CreateChatCompletion(ctx context.Context, request ChatCompletionRequest) (*ChatCompletionResponse, error) {
+	request.Stream = false
+	request.StreamOptions = nil
+	resp := &ChatCompletionResponse{}
+	if err := c.client.JSONPredict(ctx, request, resp, WithSubPath(ChatCompletionsPath)); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// CreateChatCompletionStream sends the request and returns the generated chunks as a stream,
+// which must be closed by the caller.
+func (c *ChatClient) CreateChatCompletionStream(ctx context.Context, request ChatCompletionRequest) (*ChatCompletionStream, error) {
+	request.Stream = true
+	data, err := c.client.codec().Marshal(request)
+	if err != nil {
+		return nil, NewPredictError(ErrorCodeCreateRequest, "", err.Error())
+	}
+	stream, err := c.client.PredictStream(ctx, data, WithSubPath(ChatCompletionsPath),
+		WithMethod(http.MethodPost), WithContentType("application/json"))
+	if err != nil {
+		return nil, err
+	}
+	return &ChatCompletionStream{stream: stream, codec: c.client.codec()}, nil
+}
+
+// ChatCompletionStream iterates over the chunks of streaming chat completions.
+type ChatCompletionStream struct {
+	stream *EventStream
+	codec  JSONCodec
+	usage  *Usage
+}
+
+// Recv returns the next chunk, it returns io.EOF after the last chunk.
+func (s *ChatCompletionStream) Recv() (*ChatCompletionChunk, error) {
+	for {
+		event, err := s.stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		data := strings.TrimSpace(event.Data)
+		if data == chatStreamDone {
+			return nil, io.EOF
+		}
+		if len(data) == 0 {
+			continue
+		}
+		chunk := &ChatCompletionChunk{}
+		if err := s.codec.Unmarshal([]byte(data), chunk); err != nil {
+			return nil, NewPredictError(ErrorCodeReadResponse, "", err.Error())
+		}
+		if chunk.Usage != nil {
+			s.usage = chunk.Usage
+		}
+		return chunk, nil
+	}
+}
+
+// Usage returns the token usage reported by the service, it's only available after the stream
+// is drained and when it is requested by StreamOptions.IncludeUsage.
+func (s *ChatCompletionStream) Usage() *Usage {
+	return s.usage
+}
+
+// Close closes the stream.
+func (s *ChatCompletionStream) Close() error {
+	return s.stream.Close()
+}
+
+// ChatCompletionAccumulator merges the chunks of a stream into a whole response, including the
+// content and the tool calls whose names and arguments are spread over several deltas.
+type ChatCompletionAccumulator struct {
+	response ChatCompletionResponse
+}
+
+// Add merges a chunk into the response.
+func (a *ChatCompletionAccumulator) Add(chunk *ChatCompletionChunk) {
+	r := &a.response
+	if len(chunk.ID) != 0 {
+		r.ID, r.Model, r.Created = chunk.ID, chunk.Model, chunk.Created
+		r.Object = "chat.completion"
+	}
+	if chunk.Usage != nil {
+		r.Usage = chunk.Usage
+	}
+	for _, delta := range chunk.Choices {
+		for len(r.Choices) <= delta.Index {
+			r.Choices = append(r.Choices, ChatCompletionChoice{Index: len(r.Choices)})
+		}
+		choice := &r.Choices[delta.Index]
+		if len(delta.Delta.Role) != 0 {
+			choice.Message.Role = delta.Delta.Role
+		}
+		choice.Message.Content += delta.Delta.Content
+		if len(delta.FinishReason) != 0 {
+			choice.FinishReason = delta.FinishReason
+		}
+		for _, call := range delta.Delta.ToolCalls {
+			i := len(choice.Message.ToolCalls)
+			if call.Index != nil {
+				i = *call.Index
+			}
+			for len(choice.Message.ToolCalls) <= i {
+				choice.Message.ToolCalls = append(choice.Message.ToolCalls, ToolCall{})
+			}
+			merged := &choice.Message.ToolCalls[i]
+			if len(call.ID) != 0 {
+				merged.ID = call.ID
+			}
+			if len(call.Type) != 0 {
+				merged.Type = call.Type
+			}
+			merged.Function.Name += call.Function.Name
+			merged.Function.Arguments += call.Function.Arguments
+		}
+	}
+}
+
+// Response returns the merged response.
+func (a *ChatCompletionAccumulator) Response() *ChatCompletionResponse {
+	return &a.response
+}
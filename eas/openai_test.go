@@ -0,0 +1,95 @@
+package eas
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newChatServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/predict/llm"+ChatCompletionsPath {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected content type: %s", r.Header.Get("Content-Type"))
+		}
+		request := ChatCompletionRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if !request.Stream {
+			json.NewEncoder(w).Encode(ChatCompletionResponse{
+				ID:      "chatcmpl-1",
+				Choices: []ChatCompletionChoice{{Message: ChatMessage{Role: ChatRoleAssistant, Content: "hi"}, FinishReason: "stop"}},
+				Usage:   &Usage{PromptTokens: 3, CompletionTokens: 1, TotalTokens: 4},
+			})
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		chunks := []string{
+			`{"id":"chatcmpl-2","choices":[{"index":0,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":""}}]}}]}`,
+			`{"id":"chatcmpl-2","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]}}]}`,
+			`{"id":"chatcmpl-2","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Hangzhou\"}"}}]},"finish_reason":"tool_calls"}]}`,
+			`{"id":"chatcmpl-2","choices":[],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`,
+			chatStreamDone,
+		}
+		for _, chunk := range chunks {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+	}))
+}
+
+func TestChatCompletion(t *testing.T) {
+	server := newChatServer(t)
+	defer server.Close()
+	client := NewPredictClient(server.Listener.Addr().String(), "llm")
+	client.SetToken("token")
+	client.Init()
+
+	chat := NewChatClient(client)
+	resp, err := chat.CreateChatCompletion(context.Background(), ChatCompletionRequest{
+		Model:    "qwen",
+		Messages: []ChatMessage{{Role: ChatRoleUser, Content: "hello"}},
+	})
+	assertNoError(t, err)
+	assertEqual(t, resp.Choices[0].Message.Content, "hi")
+	assertEqual(t, resp.Usage.TotalTokens, 4)
+}
+
+func TestChatCompletionStream(t *testing.T) {
+	server := newChatServer(t)
+	defer server.Close()
+	client := NewPredictClient(server.Listener.Addr().String(), "llm")
+	client.Init()
+
+	chat := NewChatClient(client)
+	stream, err := chat.CreateChatCompletionStream(context.Background(), ChatCompletionRequest{
+		Model:         "qwen",
+		Messages:      []ChatMessage{{Role: ChatRoleUser, Content: "weather in Hangzhou?"}},
+		Tools:         []Tool{{Type: "function", Function: FunctionDefinition{Name: "get_weather"}}},
+		StreamOptions: &StreamOptions{IncludeUsage: true},
+	})
+	assertNoError(t, err)
+	defer stream.Close()
+	acc := ChatCompletionAccumulator{}
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		assertNoError(t, err)
+		acc.Add(chunk)
+	}
+	resp := acc.Response()
+	call := resp.Choices[0].Message.ToolCalls[0]
+	assertEqual(t, call.ID, "call_1")
+	assertEqual(t, call.Function.Name, "get_weather")
+	assertEqual(t, call.Function.Arguments, `{"city":"Hangzhou"}`)
+	assertEqual(t, resp.Choices[0].FinishReason, "tool_calls")
+	assertEqual(t, stream.Usage().TotalTokens, 15)
+}
@@ -1,8 +1,9 @@
 package eas
 
 import (
-	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"sync/atomic"
@@ -25,6 +26,7 @@ const (
 	ErrorCodeCreateRequest    = 511
 	ErrorCodePerformRequest   = 512
 	ErrorCodeReadResponse     = 513
+	ErrorCodeCompressRequest  = 514
 )
 
 // PredictError is a custom err type
@@ -62,6 +64,10 @@ type PredictClient struct {
 	serviceName        string
 	stop               int32
 	client             http.Client
+
+	compression          string
+	compressionThreshold int
+	jsonCodec            JSONCodec
 }
 
 // NewPredictClient returns an instance of PredictClient
@@ -72,6 +78,8 @@ func NewPredictClient(endpointName string, serviceName string) *PredictClient {
 		retryCount:   5,
 		stop:         0,
 		headers:      map[string]string{},
+
+		compressionThreshold: DefaultCompressionThreshold,
 		client: http.Client{
 			Timeout: 5000 * time.Millisecond,
 			Transport: &http.Transport{
@@ -83,6 +91,9 @@ func NewPredictClient(endpointName string, serviceName string) *PredictClient {
 
 // Init initializes the predict client to create and enable endpoint discovery
 func (p *PredictClient) Init() error {
+	if err := validCompression(p.compression); err != nil {
+		return NewPredictError(http.StatusBadRequest, "", err.Error())
+	}
 	switch p.endpointType {
 	case "":
 		p.endpoint = newGatewayEndpoint(p.endpointName)
@@ -100,7 +111,7 @@ func (p *PredictClient) Init() error {
 	return nil
 }
 
-//  Shutdown after called this client instance should not be used again
+// Shutdown after called this client instance should not be used again
 func (p *PredictClient) Shutdown() {
 	atomic.StoreInt32(&(p.stop), 1)
 }
@@ -158,6 +169,18 @@ func (p *PredictClient) SetTimeout(timeout int) {
 	p.client.Timeout = time.Duration(timeout) * time.Millisecond
 }
 
+// SetCompression sets the algorithm used to compress request bodies, "gzip" or "zstd",
+// compression is disabled by default. Compressed responses are always decoded transparently.
+func (p *PredictClient) SetCompression(compression string) {
+	p.compression = compression
+}
+
+// SetCompressionThreshold sets the minimum request size in bytes to be compressed,
+// 1024 by default
+func (p *PredictClient) SetCompressionThreshold(threshold int) {
+	p.compressionThreshold = threshold
+}
+
 // SetServiceName sets target service name for client
 func (p *PredictClient) SetServiceName(serviceName string) {
 	p.serviceName = serviceName
@@ -167,65 +190,177 @@ func (p *PredictClient) tryNext(host string) string {
 	return p.endpoint.TryNext(host)
 }
 
-func (p *PredictClient) createUrl(host string) string {
+func (p *PredictClient) createUrl(host string, subPath string) string {
 	if len(p.serviceName) != 0 {
 		if p.serviceName[len(p.serviceName)-1] == '/' {
 			p.serviceName = p.serviceName[:len(p.serviceName)-1]
 		}
 	}
-	return fmt.Sprintf("http://%s/api/predict/%s", host, p.serviceName)
+	return fmt.Sprintf("http://%s/api/predict/%s%s", host, p.serviceName, subPath)
 }
 
 // generateSignature computes the signature header using the access token with hmac sha1 algorithm.
 // returns the headers including signature header for authentication.
-func (p *PredictClient) generateSignature(requestData []byte) map[string]string {
-	canonicalizedResource := fmt.Sprintf("/api/predict/%s", p.serviceName)
-	contentMd5 := md5sum(requestData)
-	contentType := "application/octet-stream"
+func (p *PredictClient) generateSignature(verb string, subPath string, contentType string, body *requestBody) map[string]string {
+	canonicalizedResource := fmt.Sprintf("/api/predict/%s%s", p.serviceName, subPath)
 	currentTime := time.Now().Format("Mon, 02 Jan 2006 15:04:05 GMT")
-	verb := "POST"
 
-	auth := fmt.Sprintf("%s\n%s\n%s\n%s\n%s", verb, contentMd5, contentType, currentTime, canonicalizedResource)
+	auth := fmt.Sprintf("%s\n%s\n%s\n%s\n%s", verb, body.contentMd5, contentType, currentTime, canonicalizedResource)
 	authorization := fmt.Sprintf("EAS %s", hmacSha256(auth, p.token))
 
-	return map[string]string{
-		"Content-MD5":    contentMd5,
-		"Date":           currentTime,
-		"Content-Type":   contentType,
-		"Content-Length": fmt.Sprintf("%d", len(requestData)),
-		"Authorization":  authorization,
+	headers := map[string]string{
+		"Date":          currentTime,
+		"Content-Type":  contentType,
+		"Authorization": authorization,
 	}
+	if len(body.contentMd5) != 0 {
+		headers["Content-MD5"] = body.contentMd5
+	}
+	if body.length >= 0 {
+		headers["Content-Length"] = fmt.Sprintf("%d", body.length)
+	}
+	return headers
 }
 
-// BytesPredict send the raw request data in byte array through http connections,
-// retry the request automatically when an error occurs
-func (p *PredictClient) BytesPredict(requestData []byte) ([]byte, error) {
-	host := p.tryNext("")
-	headers := p.generateSignature(requestData)
+// predictCall describes a request to the service.
+type predictCall struct {
+	verb        string
+	subPath     string
+	contentType string
+	accept      string
+	header      map[string]string
+	body        *requestBody
+	// noRetry sends the request once, e.g. for probes whose failures are answers.
+	noRetry bool
+}
+
+// newPredictCall returns a POST request of the service path carrying the body, modified by options.
+func newPredictCall(body *requestBody, opts ...PredictOption) *predictCall {
+	call := &predictCall{
+		verb:        http.MethodPost,
+		contentType: "application/octet-stream",
+		body:        body,
+	}
+	for _, opt := range opts {
+		opt(call)
+	}
+	return call
+}
+
+// PredictOption customizes a single request sent to the service.
+type PredictOption func(*predictCall)
+
+// WithSubPath sends the request to a sub path under the service path, e.g. "/v1/chat/completions"
+// targets "/api/predict/<service>/v1/chat/completions". The sub path is covered by the signature.
+func WithSubPath(subPath string) PredictOption {
+	return func(c *predictCall) {
+		if len(subPath) != 0 && subPath[0] != '/' {
+			subPath = "/" + subPath
+		}
+		c.subPath = subPath
+	}
+}
+
+// WithMethod sets the http method of the request, POST by default.
+func WithMethod(method string) PredictOption {
+	return func(c *predictCall) {
+		c.verb = method
+	}
+}
+
+// WithContentType sets the content type of the request, "application/octet-stream" by default.
+func WithContentType(contentType string) PredictOption {
+	return func(c *predictCall) {
+		c.contentType = contentType
+	}
+}
+
+// WithAccept sets the Accept header of the request.
+func WithAccept(accept string) PredictOption {
+	return func(c *predictCall) {
+		c.accept = accept
+	}
+}
+
+// withoutRetry sends the request only once.
+func withoutRetry() PredictOption {
+	return func(c *predictCall) {
+		c.noRetry = true
+	}
+}
+
+// WithHeader sets an extra header of the request.
+func WithHeader(headerName, headerValue string) PredictOption {
+	return func(c *predictCall) {
+		if c.header == nil {
+			c.header = map[string]string{}
+		}
+		c.header[headerName] = headerValue
+	}
+}
+
+// perform sends the request to the service, and retries it on another endpoint when an error occurs.
+// handle is called with every response received, it takes the ownership of the response body and
+// reports whether the request may be retried when it returns an error. Requests whose body can not
+// be replayed are never retried.
+func (p *PredictClient) perform(ctx context.Context, client *http.Client, call *predictCall,
+	handle func(resp *http.Response, url string) (bool, error)) error {
+	body := call.body
+	// the signature is computed over the bytes actually sent
+	headers := p.generateSignature(call.verb, call.subPath, call.contentType, body)
+	var lastErr error
+	host := ""
 	for i := 0; i <= p.retryCount; i++ {
 		if i != 0 {
-			host = p.tryNext(host)
+			if call.noRetry || !body.replayable() || ctx.Err() != nil {
+				return lastErr
+			}
 		}
+		host = p.tryNext(host)
 
 		if len(host) == 0 {
-			return nil, NewPredictError(ErrorCodeServiceDiscovery, host,
+			return NewPredictError(ErrorCodeServiceDiscovery, host,
 				fmt.Sprintf("No available endpoint found for service: %v", p.serviceName))
 		}
 
-		url := p.createUrl(host)
+		url := p.createUrl(host, call.subPath)
 
-		req, err := http.NewRequest("POST", url, bytes.NewReader(requestData))
+		reader, err := body.open()
 		if err != nil {
-			// retry
-			if i != p.retryCount {
-				continue
+			return NewPredictError(ErrorCodeCreateRequest, url, err.Error())
+		}
+		req, err := http.NewRequestWithContext(ctx, call.verb, url, reader)
+		if err != nil {
+			if closer, ok := reader.(io.Closer); ok {
+				closer.Close()
 			}
-			return nil, NewPredictError(ErrorCodeCreateRequest, url, err.Error())
+			// retry
+			lastErr = NewPredictError(ErrorCodeCreateRequest, url, err.Error())
+			continue
+		}
+		req.ContentLength = body.length
+		if body.reader == nil && body.data != nil {
+			req.GetBody = body.getBody
 		}
 		if p.token != "" {
 			for headerName, headerValue := range headers {
 				req.Header.Set(headerName, headerValue)
 			}
+		} else {
+			req.Header.Set("Content-Type", call.contentType)
+		}
+		if len(call.accept) != 0 {
+			req.Header.Set("Accept", call.accept)
+		}
+		for headerName, headerValue := range call.header {
+			req.Header.Set(headerName, headerValue)
+		}
+
+		if len(body.encoding) != 0 {
+			req.Header.Set(headerContentEncoding, body.encoding)
+		}
+		if p.compression != CompressionNone {
+			req.Header.Set(headerAcceptEncoding, acceptEncoding())
 		}
 
 		for headerName, headerValue := range p.headers {
@@ -236,59 +371,176 @@ func (p *PredictClient) BytesPredict(requestData []byte) ([]byte, error) {
 			req.Host = p.host
 		}
 
-		resp, err := p.client.Do(req)
+		resp, err := client.Do(req)
 		if err != nil {
 			// retry
-			if i != p.retryCount {
-				continue
-			}
-			return nil, NewPredictError(ErrorCodePerformRequest, url, err.Error())
+			lastErr = NewPredictError(ErrorCodePerformRequest, url, err.Error())
+			continue
 		}
 
-		body, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			// retry
-			if i != p.retryCount {
-				continue
-			}
-			return nil, NewPredictError(ErrorCodeReadResponse, url, err.Error())
+		retry, err := handle(resp, url)
+		if err == nil {
+			return nil
 		}
+		lastErr = err
+		if !retry {
+			return err
+		}
+	}
+	return lastErr
+}
+
+// BytesPredict send the raw request data in byte array through http connections,
+// retry the request automatically when an error occurs
+func (p *PredictClient) BytesPredict(requestData []byte) ([]byte, error) {
+	return p.BytesPredictWithContext(context.Background(), requestData)
+}
+
+// BytesPredictWithContext sends the raw request data like BytesPredict, the request is bounded by
+// the context, and can be customized by options, e.g. to target a sub path with another http method.
+func (p *PredictClient) BytesPredictWithContext(ctx context.Context, requestData []byte, opts ...PredictOption) ([]byte, error) {
+	body, _, err := p.bytesPredict(ctx, requestData, nil, opts...)
+	return body, err
+}
+
+// bytesPredict sends the request and returns the body and the headers of response. If release is not
+// nil, it is called once requestData is not referred by the request anymore.
+func (p *PredictClient) bytesPredict(ctx context.Context, requestData []byte, release func(), opts ...PredictOption) ([]byte, http.Header, error) {
+	compressed, encoding, err := compress(p.compression, p.compressionThreshold, requestData)
+	if err != nil {
+		if release != nil {
+			release()
+		}
+		return nil, nil, NewPredictError(ErrorCodeCompressRequest, "", err.Error())
+	}
+	requestBody := newBytesBody(compressed, encoding)
+	requestBody.release = release
+	defer requestBody.finish()
+	var body []byte
+	var header http.Header
+	call := newPredictCall(requestBody, opts...)
+	err = p.perform(ctx, &p.client, call, func(resp *http.Response, url string) (bool, error) {
+		data, err := readBody(resp)
 		resp.Body.Close()
+		if err == nil {
+			data, err = decompress(resp.Header.Get(headerContentEncoding), data)
+		}
+		if err != nil {
+			body = nil
+			return true, NewPredictError(ErrorCodeReadResponse, url, err.Error())
+		}
+		body, header = data, resp.Header
+		if resp.StatusCode != 200 {
+			return true, NewPredictError(resp.StatusCode, url, string(data))
+		}
+		return false, nil
+	})
+	return body, header, err
+}
+
+// readBody reads the whole response body into a buffer of the exact size if the length is known,
+// saving the copies of growing the buffer for large responses.
+func readBody(resp *http.Response) ([]byte, error) {
+	if resp.ContentLength <= 0 || resp.Uncompressed {
+		return ioutil.ReadAll(resp.Body)
+	}
+	data := make([]byte, resp.ContentLength)
+	_, err := io.ReadFull(resp.Body, data)
+	return data, err
+}
 
+// StreamPredict sends the request data read from the reader and returns the response body as a stream,
+// which must be closed by the caller. It is intended for large inputs and outputs, neither of them is
+// buffered in memory. The request is retried only when the reader implements io.Seeker, so that the
+// body can be replayed, and the content md5 is only signed in that case. The timeout set by SetTimeout
+// does not apply to streams, use the context to bound the request instead.
+func (p *PredictClient) StreamPredict(ctx context.Context, reader io.Reader, opts ...PredictOption) (io.ReadCloser, error) {
+	body, err := newStreamBody(reader)
+	if err != nil {
+		return nil, NewPredictError(ErrorCodeCreateRequest, "", err.Error())
+	}
+	var stream io.ReadCloser
+	call := newPredictCall(body, opts...)
+	err = p.perform(ctx, p.streamClient(), call, func(resp *http.Response, url string) (bool, error) {
 		if resp.StatusCode != 200 {
-			// retry
-			if i != p.retryCount {
-				continue
-			}
-			return body, NewPredictError(resp.StatusCode, url, string(body))
+			message := readMessage(resp.Body)
+			resp.Body.Close()
+			return true, NewPredictError(resp.StatusCode, url, message)
 		}
-		return body, nil
+		r, err := decompressReader(resp.Header.Get(headerContentEncoding), resp.Body)
+		if err != nil {
+			resp.Body.Close()
+			return true, NewPredictError(ErrorCodeReadResponse, url, err.Error())
+		}
+		stream = r
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return []byte{}, nil
+	return stream, nil
+}
+
+// streamClient returns a http client sharing the connection pool with the client of predictions,
+// but without the overall timeout which would interrupt reading a long stream.
+func (p *PredictClient) streamClient() *http.Client {
+	return &http.Client{Transport: p.client.Transport}
 }
 
 type Request interface {
 	ToString() (string, error)
 }
 
+// BytesRequest is implemented by requests able to serialize themselves into bytes directly,
+// which saves the copy of converting through string.
+type BytesRequest interface {
+	ToBytes() ([]byte, error)
+}
+
+// requestBytes serializes the request, avoiding the string round-trip when it's possible.
+func requestBytes(request Request) ([]byte, error) {
+	if r, ok := request.(BytesRequest); ok {
+		return r.ToBytes()
+	}
+	str, err := request.ToString()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(str), nil
+}
+
 type Response interface {
 	unmarshal(body []byte) error
 }
 
 // Predict for request
 func (p *PredictClient) Predict(request Request) (Response, error) {
-	req, err2 := request.ToString()
+	return p.PredictWithContext(context.Background(), request)
+}
+
+// PredictWithContext sends the request like Predict, the request is bounded by the context,
+// and can be customized by options, e.g. to target the REST API path of TensorFlow Serving.
+func (p *PredictClient) PredictWithContext(ctx context.Context, request Request, opts ...PredictOption) (Response, error) {
+	if r, ok := request.(ValidatedRequest); ok {
+		if err := r.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	req, release, err2 := encodeRequest(request)
 	if err2 != nil {
 		return nil, err2
 	}
-	body, err := p.BytesPredict([]byte(req))
+	if r, ok := request.(TFRequest); ok && r.format != TFFormatProtobuf {
+		opts = append([]PredictOption{WithContentType("application/json")}, opts...)
+	}
+	body, _, err := p.bytesPredict(ctx, req, release, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	switch request.(type) {
+	switch r := request.(type) {
 	case TFRequest:
-		resp := TFResponse{}
+		resp := TFResponse{format: r.format, fetches: r.RequestData.OutputFilter}
 		unmarshalErr := resp.unmarshal(body)
 		return &resp, unmarshalErr
 	case TorchRequest:
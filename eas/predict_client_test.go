@@ -1,7 +1,14 @@
 package eas
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -72,3 +79,54 @@ func TestTorch(t *testing.T) {
 	}
 	fmt.Println("average response time : ", time.Since(st)/10)
 }
+
+func TestStreamPredictRetry(t *testing.T) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Header.Get("Content-MD5") != md5sum(body) {
+			t.Errorf("unexpected content md5: %s", r.Header.Get("Content-MD5"))
+		}
+		if atomic.AddInt32(&count, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	client := NewPredictClient(server.Listener.Addr().String(), "test")
+	client.SetToken("token")
+	client.Init()
+	data := bytes.Repeat([]byte("stream"), 1<<16)
+	stream, err := client.StreamPredict(context.Background(), bytes.NewReader(data))
+	assertNoError(t, err)
+	defer stream.Close()
+	resp, err := ioutil.ReadAll(stream)
+	assertNoError(t, err)
+	if !bytes.Equal(resp, data) {
+		t.Fatalf("unexpected response of %d bytes", len(resp))
+	}
+	assertEqual(t, atomic.LoadInt32(&count), int32(2))
+}
+
+func TestStreamPredictNoRetry(t *testing.T) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewPredictClient(server.Listener.Addr().String(), "test")
+	client.Init()
+	// a reader which is not seekable can not be replayed
+	reader := io.MultiReader(bytes.NewReader([]byte("stream")))
+	_, err := client.StreamPredict(context.Background(), reader)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	assertEqual(t, err.(*PredictError).Code, http.StatusServiceUnavailable)
+	assertEqual(t, atomic.LoadInt32(&count), int32(1))
+}
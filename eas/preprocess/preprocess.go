@@ -0,0 +1,330 @@
+// Package preprocess converts JPEG and PNG images into tensors ready to be fed to vision models,
+// with the resize, center crop, normalization and layout commonly used by TensorFlow and PyTorch.
+// Only the image decoders of the standard library are used.
+package preprocess
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/jpeg" // register the JPEG decoder
+	_ "image/png"  // register the PNG decoder
+	"io"
+	"math"
+
+	"github.com/pai-eas/eas-golang-sdk/eas"
+)
+
+// Layout is the order of dimensions of the image tensor.
+type Layout string
+
+const (
+	// LayoutNCHW is [batch, channels, height, width], used by PyTorch models.
+	LayoutNCHW Layout = "NCHW"
+	// LayoutNHWC is [batch, height, width, channels], used by TensorFlow models.
+	LayoutNHWC Layout = "NHWC"
+)
+
+// Default size of the image tensor.
+const (
+	DefaultWidth  = 224
+	DefaultHeight = 224
+)
+
+// ImageNet mean and std of RGB channels, applied after pixels are scaled into [0, 1].
+var (
+	ImageNetMean = [3]float32{0.485, 0.456, 0.406}
+	ImageNetStd  = [3]float32{0.229, 0.224, 0.225}
+)
+
+type options struct {
+	width   int
+	height  int
+	resize  int
+	scale   float32
+	mean    [3]float32
+	std     [3]float32
+	layout  Layout
+	bgr     bool
+	noBatch bool
+}
+
+// Option customizes how images are converted to tensors.
+type Option func(*options)
+
+func newOptions(layout Layout, opts []Option) *options {
+	o := &options{
+		width:  DefaultWidth,
+		height: DefaultHeight,
+		scale:  1.0 / 255,
+		std:    [3]float32{1, 1, 1},
+		layout: layout,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithSize sets the width and height of the image tensor, 224x224 by default.
+func WithSize(width, height int) Option {
+	return func(o *options) {
+		o.width = width
+		o.height = height
+	}
+}
+
+// WithResize resizes the shorter side of image to the length keeping the aspect ratio, and then crops
+// the center of the size set by WithSize, e.g. 256 for the common ImageNet evaluation. By default the
+// whole image is resized to the size directly.
+func WithResize(shorter int) Option {
+	return func(o *options) {
+		o.resize = shorter
+	}
+}
+
+// WithScale sets the factor pixel values in [0, 255] are multiplied by before normalization, 1/255 by default.
+func WithScale(scale float32) Option {
+	return func(o *options) {
+		o.scale = scale
+	}
+}
+
+// WithMeanStd normalizes the scaled value of each channel c by (value - mean[c]) / std[c], the channels
+// are in RGB order. Mean 0 and std 1 by default, ImageNetMean and ImageNetStd are the common choice.
+func WithMeanStd(mean, std [3]float32) Option {
+	return func(o *options) {
+		o.mean = mean
+		o.std = std
+	}
+}
+
+// WithLayout sets the layout of the image tensor.
+func WithLayout(layout Layout) Option {
+	return func(o *options) {
+		o.layout = layout
+	}
+}
+
+// WithBGR orders channels as BGR in the tensor, as models trained with OpenCV expect.
+func WithBGR() Option {
+	return func(o *options) {
+		o.bgr = true
+	}
+}
+
+// WithoutBatch omits the batch dimension of the image tensor, e.g. [3, 224, 224] for NCHW.
+func WithoutBatch() Option {
+	return func(o *options) {
+		o.noBatch = true
+	}
+}
+
+// Decode decodes a JPEG or PNG image.
+func Decode(r io.Reader) (image.Image, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %v", err)
+	}
+	return img, nil
+}
+
+// ImageTensor converts the image into a DT_FLOAT tensor of shape [1, 3, height, width] for NCHW layout,
+// or [1, height, width, 3] for NHWC layout, which is the default. The alpha channel is dropped, the
+// colors are taken as they are without being premultiplied by alpha. Images are enlarged by bilinear
+// interpolation, and shrunk by averaging the source pixels each output pixel covers, so they are not
+// aliased.
+func ImageTensor(img image.Image, opts ...Option) (*eas.Tensor, error) {
+	return imageTensor(img, newOptions(LayoutNHWC, opts))
+}
+
+// ReadTensor decodes the JPEG or PNG image from the reader and converts it like ImageTensor.
+func ReadTensor(r io.Reader, opts ...Option) (*eas.Tensor, error) {
+	img, err := Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	return ImageTensor(img, opts...)
+}
+
+// FeedTF decodes the image and adds it as the input named inputName of TFRequest, in NHWC layout by default.
+func FeedTF(req *eas.TFRequest, inputName string, r io.Reader, opts ...Option) error {
+	img, err := Decode(r)
+	if err != nil {
+		return err
+	}
+	tensor, err := imageTensor(img, newOptions(LayoutNHWC, opts))
+	if err != nil {
+		return err
+	}
+	req.AddFeedTensor(inputName, tensor)
+	return nil
+}
+
+// FeedTorch decodes the image and adds it as the input at index of TorchRequest, in NCHW layout by default.
+func FeedTorch(req *eas.TorchRequest, index int, r io.Reader, opts ...Option) error {
+	img, err := Decode(r)
+	if err != nil {
+		return err
+	}
+	tensor, err := imageTensor(img, newOptions(LayoutNCHW, opts))
+	if err != nil {
+		return err
+	}
+	req.AddFeedTensor(index, tensor)
+	return nil
+}
+
+func imageTensor(img image.Image, o *options) (*eas.Tensor, error) {
+	if o.width <= 0 || o.height <= 0 {
+		return nil, fmt.Errorf("invalid size %dx%d", o.width, o.height)
+	}
+	if o.layout != LayoutNCHW && o.layout != LayoutNHWC {
+		return nil, fmt.Errorf("unknown layout %s", o.layout)
+	}
+	for _, std := range o.std {
+		if std == 0 {
+			return nil, fmt.Errorf("std must not be zero")
+		}
+	}
+	bounds := img.Bounds()
+	src, ok := img.(*image.NRGBA)
+	if !ok || bounds.Min != (image.Point{}) {
+		// NRGBA keeps the colors of translucent pixels, which RGBA would premultiply by alpha
+		src = image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+		draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+	}
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	if srcW == 0 || srcH == 0 {
+		return nil, fmt.Errorf("empty image")
+	}
+
+	// the size of image after resizing, the output is cropped from its center
+	resizedW, resizedH := float64(o.width), float64(o.height)
+	if o.resize > 0 {
+		ratio := float64(o.resize) / math.Min(float64(srcW), float64(srcH))
+		resizedW, resizedH = math.Round(float64(srcW)*ratio), math.Round(float64(srcH)*ratio)
+		if resizedW < float64(o.width) || resizedH < float64(o.height) {
+			return nil, fmt.Errorf("image resized to %vx%v is smaller than crop size %dx%d", resizedW, resizedH, o.width, o.height)
+		}
+	}
+	offsetX := math.Round((resizedW - float64(o.width)) / 2)
+	offsetY := math.Round((resizedH - float64(o.height)) / 2)
+	scaleX, scaleY := float64(srcW)/resizedW, float64(srcH)/resizedH
+	tapsX := axisTaps(o.width, srcW, offsetX, scaleX)
+	tapsY := axisTaps(o.height, srcH, offsetY, scaleY)
+
+	channels := [3]int{0, 1, 2}
+	if o.bgr {
+		channels = [3]int{2, 1, 0}
+	}
+	plane := o.width * o.height
+	data := make([]float32, 3*plane)
+	var rgb [3]float32
+	for y := 0; y < o.height; y++ {
+		for x := 0; x < o.width; x++ {
+			sample(src, tapsX[x], tapsY[y], &rgb)
+			for c, from := range channels {
+				value := (rgb[from]*o.scale - o.mean[from]) / o.std[from]
+				if o.layout == LayoutNCHW {
+					data[c*plane+y*o.width+x] = value
+				} else {
+					data[(y*o.width+x)*3+c] = value
+				}
+			}
+		}
+	}
+
+	shape := []int64{3, int64(o.height), int64(o.width)}
+	if o.layout == LayoutNHWC {
+		shape = []int64{int64(o.height), int64(o.width), 3}
+	}
+	if !o.noBatch {
+		shape = append([]int64{1}, shape...)
+	}
+	return eas.NewTensor(eas.TfType_DT_FLOAT, shape, data)
+}
+
+// tap is a source pixel along an axis and its weight in an output pixel.
+type tap struct {
+	index  int
+	weight float32
+}
+
+// axisTaps returns the source pixels of n output pixels along an axis of srcN pixels, where output
+// pixel i starts at (i+offset)*scale in source. Enlarged axes are interpolated linearly between the
+// two nearest pixels with half-pixel centers, shrunk ones average the pixels covered weighted by the
+// coverage.
+func axisTaps(n, srcN int, offset, scale float64) [][]tap {
+	taps := make([][]tap, n)
+	for i := range taps {
+		if scale <= 1 {
+			s := (float64(i)+offset+0.5)*scale - 0.5
+			s = math.Max(0, math.Min(s, float64(srcN-1)))
+			j0 := int(s)
+			j1 := j0 + 1
+			if j1 >= srcN {
+				j1 = srcN - 1
+			}
+			f := float32(s - float64(j0))
+			taps[i] = []tap{{j0, 1 - f}, {j1, f}}
+			continue
+		}
+		lo := math.Max(0, (float64(i)+offset)*scale)
+		hi := math.Min(float64(srcN), lo+scale)
+		var total float32
+		for j := int(lo); float64(j) < hi; j++ {
+			w := float32(math.Min(hi, float64(j+1)) - math.Max(lo, float64(j)))
+			if w > 0 {
+				taps[i] = append(taps[i], tap{j, w})
+				total += w
+			}
+		}
+		for k := range taps[i] {
+			taps[i][k].weight /= total
+		}
+	}
+	return taps
+}
+
+// sample computes the RGB values of an output pixel from the source pixels of its taps.
+func sample(src *image.NRGBA, tapsX, tapsY []tap, rgb *[3]float32) {
+	*rgb = [3]float32{}
+	for _, ty := range tapsY {
+		for _, tx := range tapsX {
+			w := tx.weight * ty.weight
+			p := src.PixOffset(tx.index, ty.index)
+			for c := 0; c < 3; c++ {
+				rgb[c] += float32(src.Pix[p+c]) * w
+			}
+		}
+	}
+}
+
+// Batch stacks image tensors of the same shape with the batch dimension into one tensor.
+func Batch(tensors ...*eas.Tensor) (*eas.Tensor, error) {
+	if len(tensors) == 0 {
+		return nil, fmt.Errorf("no tensor to batch")
+	}
+	shape := tensors[0].Shape()
+	if len(shape) == 0 {
+		return nil, fmt.Errorf("scalar tensor can not be batched")
+	}
+	var data []float32
+	batch := int64(0)
+	for _, tensor := range tensors {
+		other := tensor.Shape()
+		if len(other) != len(shape) || tensor.Dtype() != eas.TfType_DT_FLOAT {
+			return nil, fmt.Errorf("tensor of %v %v differs from %v", tensor.Dtype(), other, shape)
+		}
+		for i := 1; i < len(shape); i++ {
+			if other[i] != shape[i] {
+				return nil, fmt.Errorf("tensor of shape %v differs from %v", other, shape)
+			}
+		}
+		data = append(data, tensor.Data().([]float32)...)
+		batch += other[0]
+	}
+	shape[0] = batch
+	return eas.NewTensor(eas.TfType_DT_FLOAT, shape, data)
+}
@@ -0,0 +1,129 @@
+package preprocess
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/pai-eas/eas-golang-sdk/eas"
+)
+
+// newPNG encodes an image whose left half is red and right half is blue.
+func newPNG(t *testing.T, width, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x < width/2 {
+				img.Set(x, y, color.NRGBA{R: 255, A: 255})
+			} else {
+				img.Set(x, y, color.NRGBA{B: 255, A: 255})
+			}
+		}
+	}
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadTensorLayouts(t *testing.T) {
+	data := newPNG(t, 8, 4)
+	tensor, err := ReadTensor(bytes.NewReader(data), WithSize(4, 2), WithScale(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tensor.Shape(), []int64{1, 2, 4, 3}) {
+		t.Fatalf("unexpected shape: %v", tensor.Shape())
+	}
+	if tensor.At(0, 1, 0, 0) != float32(255) || tensor.At(0, 1, 3, 2) != float32(255) || tensor.At(0, 0, 0, 2) != float32(0) {
+		t.Fatalf("unexpected pixels: %v", tensor.ToSlice())
+	}
+
+	tensor, err = ReadTensor(bytes.NewReader(data), WithSize(4, 2), WithLayout(LayoutNCHW), WithBGR(),
+		WithMeanStd(ImageNetMean, ImageNetStd), WithoutBatch())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tensor.Shape(), []int64{3, 2, 4}) {
+		t.Fatalf("unexpected shape: %v", tensor.Shape())
+	}
+	// channel 2 is red in BGR order
+	red := tensor.At(2, 0, 0).(float32)
+	if math.Abs(float64(red-(1-0.485)/0.229)) > 1e-5 {
+		t.Fatalf("unexpected normalized red: %v", red)
+	}
+}
+
+func TestResizeCenterCrop(t *testing.T) {
+	// the center crop of a 16x4 image resized to 8x2 keeps pixels of both halves
+	tensor, err := ReadTensor(bytes.NewReader(newPNG(t, 16, 4)), WithResize(2), WithSize(2, 2), WithScale(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tensor.At(0, 0, 0, 0).(float32) < 127 || tensor.At(0, 0, 1, 2).(float32) < 127 {
+		t.Fatalf("unexpected crop: %v", tensor.ToSlice())
+	}
+	if _, err = ReadTensor(bytes.NewReader(newPNG(t, 16, 4)), WithResize(1), WithSize(2, 2)); err == nil {
+		t.Fatal("crop larger than the resized image should be rejected")
+	}
+}
+
+func TestFeed(t *testing.T) {
+	req := eas.TorchRequest{}
+	if err := FeedTorch(&req, 0, bytes.NewReader(newPNG(t, 4, 4)), WithSize(2, 2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := req.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(req.RequestData.Inputs[0].ArrayShape.Dim, []int64{1, 3, 2, 2}) {
+		t.Fatalf("unexpected shape: %v", req.RequestData.Inputs[0].ArrayShape.Dim)
+	}
+
+	one, _ := ReadTensor(bytes.NewReader(newPNG(t, 4, 4)), WithSize(2, 2))
+	batch, err := Batch(one, one)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(batch.Shape(), []int64{2, 2, 2, 3}) {
+		t.Fatalf("unexpected batch shape: %v", batch.Shape())
+	}
+	if err = FeedTF(&eas.TFRequest{}, "images", bytes.NewReader([]byte("not an image"))); err == nil {
+		t.Fatal("invalid image should be rejected")
+	}
+}
+
+func TestTranslucentAndShrunkPixels(t *testing.T) {
+	// translucent colors are taken as they are, not premultiplied by alpha
+	img := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	img.Set(0, 0, color.NRGBA{R: 200, G: 100, A: 128})
+	tensor, err := ImageTensor(img, WithSize(1, 1), WithScale(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tensor.At(0, 0, 0, 0) != float32(200) || tensor.At(0, 0, 0, 1) != float32(100) {
+		t.Fatalf("unexpected pixel: %v", tensor.ToSlice())
+	}
+
+	// one pixel wide stripes are averaged when shrunk instead of aliased
+	stripes := image.NewGray(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x += 2 {
+			stripes.SetGray(x, y, color.Gray{Y: 255})
+		}
+	}
+	tensor, err = ImageTensor(stripes, WithSize(2, 2), WithScale(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range tensor.Data().([]float32) {
+		if math.Abs(float64(v)-127.5) > 1e-3 {
+			t.Fatalf("unexpected shrunk pixels: %v", tensor.ToSlice())
+		}
+	}
+}
@@ -0,0 +1,550 @@
+package eas
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/pai-eas/eas-golang-sdk/eas/types/tf_predict_protos"
+	"github.com/pai-eas/eas-golang-sdk/eas/types/torch_predict_protos"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The PredictRequest and PredictResponse of TensorFlow and PyTorch processors are encoded here by hand
+// rather than by proto.Marshal and proto.Unmarshal. Packed fields are written straight from the slices
+// given to AddFeed into a pooled buffer of the exact size, and read straight into slices of the exact
+// length, bytes of DT_STRING outputs share the buffer of response body.
+
+// field numbers of ArrayProto
+const (
+	arrayFieldDtype     protowire.Number = 1
+	arrayFieldShape     protowire.Number = 2
+	arrayFieldFloatVal  protowire.Number = 3
+	arrayFieldDoubleVal protowire.Number = 4
+	arrayFieldIntVal    protowire.Number = 5
+	arrayFieldStringVal protowire.Number = 6
+	arrayFieldInt64Val  protowire.Number = 7
+	arrayFieldBoolVal   protowire.Number = 8
+)
+
+// maxPooledBufferSize is the largest request buffer put back to the pool, larger ones are left to gc
+// in case a single huge request pins the memory.
+const maxPooledBufferSize = 32 << 20
+
+var requestBufferPool = sync.Pool{New: func() interface{} { return new([]byte) }}
+
+// protoRequest is implemented by requests able to append their protobuf encoding to a buffer.
+type protoRequest interface {
+	protoSize() int
+	appendProto(b []byte) []byte
+}
+
+// encodeRequest serializes the request, protobuf requests are encoded into a pooled buffer, which is
+// recycled by calling release once nothing refers to the bytes anymore, including the transport.
+func encodeRequest(request Request) (data []byte, release func(), err error) {
+	r, ok := request.(protoRequest)
+	if tr, isTF := request.(TFRequest); !ok || (isTF && tr.format != TFFormatProtobuf) {
+		data, err = requestBytes(request)
+		return data, func() {}, err
+	}
+	size := r.protoSize()
+	buf := requestBufferPool.Get().(*[]byte)
+	if cap(*buf) < size {
+		*buf = make([]byte, 0, size)
+	}
+	data = r.appendProto((*buf)[:0])
+	return data, func() {
+		if cap(data) <= maxPooledBufferSize {
+			*buf = data[:0]
+			requestBufferPool.Put(buf)
+		}
+	}, nil
+}
+
+// wireArray is the ArrayProto of TensorFlow or PyTorch to be encoded, or decoded from a response.
+type wireArray struct {
+	dtype    int32
+	hasShape bool
+	dims     []int64
+	values   arrayValues
+}
+
+func tfWireArray(array *tf_predict_protos.ArrayProto) wireArray {
+	return wireArray{
+		dtype:    int32(array.GetDtype()),
+		hasShape: array.GetArrayShape() != nil,
+		dims:     array.GetArrayShape().GetDim(),
+		values: arrayValues{
+			floatVal:  array.GetFloatVal(),
+			doubleVal: array.GetDoubleVal(),
+			intVal:    array.GetIntVal(),
+			int64Val:  array.GetInt64Val(),
+			stringVal: array.GetStringVal(),
+			boolVal:   array.GetBoolVal(),
+		},
+	}
+}
+
+func torchWireArray(array *torch_predict_protos.ArrayProto) wireArray {
+	return wireArray{
+		dtype:    int32(array.GetDtype()),
+		hasShape: array.GetArrayShape() != nil,
+		dims:     array.GetArrayShape().GetDim(),
+		values: arrayValues{
+			floatVal:  array.GetFloatVal(),
+			doubleVal: array.GetDoubleVal(),
+			intVal:    array.GetIntVal(),
+			int64Val:  array.GetInt64Val(),
+			stringVal: array.GetStringVal(),
+		},
+	}
+}
+
+func sizeInt64s(values []int64) int {
+	n := 0
+	for _, v := range values {
+		n += protowire.SizeVarint(uint64(v))
+	}
+	return n
+}
+
+func sizeInt32s(values []int32) int {
+	n := 0
+	for _, v := range values {
+		n += protowire.SizeVarint(uint64(v))
+	}
+	return n
+}
+
+// sizePacked returns the size of a packed field holding n bytes of values, an empty one is omitted.
+func sizePacked(num protowire.Number, n int) int {
+	if n == 0 {
+		return 0
+	}
+	return protowire.SizeTag(num) + protowire.SizeBytes(n)
+}
+
+func (a *wireArray) shapeSize() int {
+	return sizePacked(1, sizeInt64s(a.dims))
+}
+
+func (a *wireArray) size() int {
+	n := 0
+	if a.dtype != 0 {
+		n += protowire.SizeTag(arrayFieldDtype) + protowire.SizeVarint(uint64(a.dtype))
+	}
+	if a.hasShape {
+		n += protowire.SizeTag(arrayFieldShape) + protowire.SizeBytes(a.shapeSize())
+	}
+	n += sizePacked(arrayFieldFloatVal, 4*len(a.values.floatVal))
+	n += sizePacked(arrayFieldDoubleVal, 8*len(a.values.doubleVal))
+	n += sizePacked(arrayFieldIntVal, sizeInt32s(a.values.intVal))
+	for _, s := range a.values.stringVal {
+		n += protowire.SizeTag(arrayFieldStringVal) + protowire.SizeBytes(len(s))
+	}
+	n += sizePacked(arrayFieldInt64Val, sizeInt64s(a.values.int64Val))
+	n += sizePacked(arrayFieldBoolVal, len(a.values.boolVal))
+	return n
+}
+
+func (a *wireArray) appendTo(b []byte) []byte {
+	if a.dtype != 0 {
+		b = protowire.AppendTag(b, arrayFieldDtype, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(a.dtype))
+	}
+	if a.hasShape {
+		b = protowire.AppendTag(b, arrayFieldShape, protowire.BytesType)
+		b = protowire.AppendVarint(b, uint64(a.shapeSize()))
+		if len(a.dims) != 0 {
+			b = protowire.AppendTag(b, 1, protowire.BytesType)
+			b = protowire.AppendVarint(b, uint64(sizeInt64s(a.dims)))
+			for _, v := range a.dims {
+				b = protowire.AppendVarint(b, uint64(v))
+			}
+		}
+	}
+	if values := a.values.floatVal; len(values) != 0 {
+		b = protowire.AppendTag(b, arrayFieldFloatVal, protowire.BytesType)
+		b = protowire.AppendVarint(b, uint64(4*len(values)))
+		for _, v := range values {
+			b = append(b, 0, 0, 0, 0)
+			binary.LittleEndian.PutUint32(b[len(b)-4:], math.Float32bits(v))
+		}
+	}
+	if values := a.values.doubleVal; len(values) != 0 {
+		b = protowire.AppendTag(b, arrayFieldDoubleVal, protowire.BytesType)
+		b = protowire.AppendVarint(b, uint64(8*len(values)))
+		for _, v := range values {
+			b = append(b, 0, 0, 0, 0, 0, 0, 0, 0)
+			binary.LittleEndian.PutUint64(b[len(b)-8:], math.Float64bits(v))
+		}
+	}
+	if values := a.values.intVal; len(values) != 0 {
+		b = protowire.AppendTag(b, arrayFieldIntVal, protowire.BytesType)
+		b = protowire.AppendVarint(b, uint64(sizeInt32s(values)))
+		for _, v := range values {
+			b = protowire.AppendVarint(b, uint64(v))
+		}
+	}
+	for _, s := range a.values.stringVal {
+		b = protowire.AppendTag(b, arrayFieldStringVal, protowire.BytesType)
+		b = protowire.AppendBytes(b, s)
+	}
+	if values := a.values.int64Val; len(values) != 0 {
+		b = protowire.AppendTag(b, arrayFieldInt64Val, protowire.BytesType)
+		b = protowire.AppendVarint(b, uint64(sizeInt64s(values)))
+		for _, v := range values {
+			b = protowire.AppendVarint(b, uint64(v))
+		}
+	}
+	if values := a.values.boolVal; len(values) != 0 {
+		b = protowire.AppendTag(b, arrayFieldBoolVal, protowire.BytesType)
+		b = protowire.AppendVarint(b, uint64(len(values)))
+		for _, v := range values {
+			b = protowire.AppendVarint(b, protowire.EncodeBool(v))
+		}
+	}
+	return b
+}
+
+func (tr TFRequest) sortedInputNames() []string {
+	names := make([]string, 0, len(tr.RequestData.Inputs))
+	for name := range tr.RequestData.Inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func tfInputEntrySize(name string, array *wireArray) int {
+	return protowire.SizeTag(1) + protowire.SizeBytes(len(name)) + protowire.SizeTag(2) + protowire.SizeBytes(array.size())
+}
+
+func (tr TFRequest) protoSize() int {
+	n := 0
+	if name := tr.RequestData.SignatureName; name != "" {
+		n += protowire.SizeTag(1) + protowire.SizeBytes(len(name))
+	}
+	for name, input := range tr.RequestData.Inputs {
+		array := tfWireArray(input)
+		n += protowire.SizeTag(2) + protowire.SizeBytes(tfInputEntrySize(name, &array))
+	}
+	for _, name := range tr.RequestData.OutputFilter {
+		n += protowire.SizeTag(3) + protowire.SizeBytes(len(name))
+	}
+	return n
+}
+
+// appendProto encodes the request like proto.Marshal, inputs are sorted by name to be deterministic.
+func (tr TFRequest) appendProto(b []byte) []byte {
+	if name := tr.RequestData.SignatureName; name != "" {
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendString(b, name)
+	}
+	for _, name := range tr.sortedInputNames() {
+		array := tfWireArray(tr.RequestData.Inputs[name])
+		b = protowire.AppendTag(b, 2, protowire.BytesType)
+		b = protowire.AppendVarint(b, uint64(tfInputEntrySize(name, &array)))
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendString(b, name)
+		b = protowire.AppendTag(b, 2, protowire.BytesType)
+		b = protowire.AppendVarint(b, uint64(array.size()))
+		b = array.appendTo(b)
+	}
+	for _, name := range tr.RequestData.OutputFilter {
+		b = protowire.AppendTag(b, 3, protowire.BytesType)
+		b = protowire.AppendString(b, name)
+	}
+	return b
+}
+
+func (tr TorchRequest) protoSize() int {
+	n := 0
+	for _, input := range tr.RequestData.Inputs {
+		array := torchWireArray(input)
+		n += protowire.SizeTag(1) + protowire.SizeBytes(array.size())
+	}
+	filter := 0
+	for _, index := range tr.RequestData.OutputFilter {
+		filter += protowire.SizeVarint(uint64(index))
+	}
+	return n + sizePacked(2, filter)
+}
+
+// appendProto encodes the request like proto.Marshal.
+func (tr TorchRequest) appendProto(b []byte) []byte {
+	for _, input := range tr.RequestData.Inputs {
+		array := torchWireArray(input)
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendVarint(b, uint64(array.size()))
+		b = array.appendTo(b)
+	}
+	if filter := tr.RequestData.OutputFilter; len(filter) != 0 {
+		b = protowire.AppendTag(b, 2, protowire.BytesType)
+		b = protowire.AppendVarint(b, uint64(sizeInt32s(filter)))
+		for _, index := range filter {
+			b = protowire.AppendVarint(b, uint64(index))
+		}
+	}
+	return b
+}
+
+// countVarints returns the number of varints in a packed field.
+func countVarints(b []byte) int {
+	n := 0
+	for _, c := range b {
+		if c < 0x80 {
+			n++
+		}
+	}
+	return n
+}
+
+func decodeVarints(b []byte, appendValue func(v uint64)) error {
+	for len(b) > 0 {
+		v, n := protowire.ConsumeVarint(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		appendValue(v)
+		b = b[n:]
+	}
+	return nil
+}
+
+// decodeArray decodes an ArrayProto, accepting both packed and unpacked repeated fields.
+func decodeArray(b []byte) (*wireArray, error) {
+	a := &wireArray{}
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		var packed []byte
+		if typ == protowire.BytesType {
+			if packed, n = protowire.ConsumeBytes(b); n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+		} else if n = protowire.ConsumeFieldValue(num, typ, b); n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		value := b[:n]
+		b = b[n:]
+
+		var err error
+		switch {
+		case num == arrayFieldDtype && typ == protowire.VarintType:
+			v, _ := protowire.ConsumeVarint(value)
+			a.dtype = int32(v)
+		case num == arrayFieldShape && typ == protowire.BytesType:
+			a.hasShape = true
+			err = decodeShape(packed, a)
+		case num == arrayFieldFloatVal && typ == protowire.BytesType:
+			if len(packed)%4 != 0 {
+				return nil, fmt.Errorf("packed float_val of %d bytes is not a multiple of 4", len(packed))
+			}
+			if a.values.floatVal == nil {
+				a.values.floatVal = make([]float32, 0, len(packed)/4)
+			}
+			for ; len(packed) >= 4; packed = packed[4:] {
+				a.values.floatVal = append(a.values.floatVal, math.Float32frombits(binary.LittleEndian.Uint32(packed)))
+			}
+		case num == arrayFieldFloatVal && typ == protowire.Fixed32Type:
+			a.values.floatVal = append(a.values.floatVal, math.Float32frombits(binary.LittleEndian.Uint32(value)))
+		case num == arrayFieldDoubleVal && typ == protowire.BytesType:
+			if len(packed)%8 != 0 {
+				return nil, fmt.Errorf("packed double_val of %d bytes is not a multiple of 8", len(packed))
+			}
+			if a.values.doubleVal == nil {
+				a.values.doubleVal = make([]float64, 0, len(packed)/8)
+			}
+			for ; len(packed) >= 8; packed = packed[8:] {
+				a.values.doubleVal = append(a.values.doubleVal, math.Float64frombits(binary.LittleEndian.Uint64(packed)))
+			}
+		case num == arrayFieldDoubleVal && typ == protowire.Fixed64Type:
+			a.values.doubleVal = append(a.values.doubleVal, math.Float64frombits(binary.LittleEndian.Uint64(value)))
+		case num == arrayFieldIntVal && typ == protowire.BytesType:
+			if a.values.intVal == nil {
+				a.values.intVal = make([]int32, 0, countVarints(packed))
+			}
+			err = decodeVarints(packed, func(v uint64) { a.values.intVal = append(a.values.intVal, int32(v)) })
+		case num == arrayFieldIntVal && typ == protowire.VarintType:
+			v, _ := protowire.ConsumeVarint(value)
+			a.values.intVal = append(a.values.intVal, int32(v))
+		case num == arrayFieldStringVal && typ == protowire.BytesType:
+			a.values.stringVal = append(a.values.stringVal, packed[:len(packed):len(packed)])
+		case num == arrayFieldInt64Val && typ == protowire.BytesType:
+			if a.values.int64Val == nil {
+				a.values.int64Val = make([]int64, 0, countVarints(packed))
+			}
+			err = decodeVarints(packed, func(v uint64) { a.values.int64Val = append(a.values.int64Val, int64(v)) })
+		case num == arrayFieldInt64Val && typ == protowire.VarintType:
+			v, _ := protowire.ConsumeVarint(value)
+			a.values.int64Val = append(a.values.int64Val, int64(v))
+		case num == arrayFieldBoolVal && typ == protowire.BytesType:
+			if a.values.boolVal == nil {
+				a.values.boolVal = make([]bool, 0, countVarints(packed))
+			}
+			err = decodeVarints(packed, func(v uint64) { a.values.boolVal = append(a.values.boolVal, v != 0) })
+		case num == arrayFieldBoolVal && typ == protowire.VarintType:
+			v, _ := protowire.ConsumeVarint(value)
+			a.values.boolVal = append(a.values.boolVal, v != 0)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+func decodeShape(b []byte, a *wireArray) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			packed, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			if err := decodeVarints(packed, func(v uint64) { a.dims = append(a.dims, int64(v)) }); err != nil {
+				return err
+			}
+			b = b[n:]
+		case num == 1 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			a.dims = append(a.dims, int64(v))
+			b = b[n:]
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+		}
+	}
+	return nil
+}
+
+func (a *wireArray) shape() []int64 {
+	if !a.hasShape {
+		return nil
+	}
+	return a.dims
+}
+
+func (a *wireArray) tfArray() *tf_predict_protos.ArrayProto {
+	array := &tf_predict_protos.ArrayProto{
+		Dtype:     tf_predict_protos.ArrayDataType(a.dtype),
+		FloatVal:  a.values.floatVal,
+		DoubleVal: a.values.doubleVal,
+		IntVal:    a.values.intVal,
+		StringVal: a.values.stringVal,
+		Int64Val:  a.values.int64Val,
+		BoolVal:   a.values.boolVal,
+	}
+	if a.hasShape {
+		array.ArrayShape = &tf_predict_protos.ArrayShape{Dim: a.shape()}
+	}
+	return array
+}
+
+func (a *wireArray) torchArray() *torch_predict_protos.ArrayProto {
+	array := &torch_predict_protos.ArrayProto{
+		Dtype:     torch_predict_protos.ArrayDataType(a.dtype),
+		FloatVal:  a.values.floatVal,
+		DoubleVal: a.values.doubleVal,
+		IntVal:    a.values.intVal,
+		StringVal: a.values.stringVal,
+		Int64Val:  a.values.int64Val,
+	}
+	if a.hasShape {
+		array.ArrayShape = &torch_predict_protos.ArrayShape{Dim: a.shape()}
+	}
+	return array
+}
+
+// forEachMessage calls handle with the content of every message field numbered num in b.
+func forEachMessage(b []byte, num protowire.Number, handle func(value []byte) error) error {
+	for len(b) > 0 {
+		fieldNum, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if fieldNum == num && typ == protowire.BytesType {
+			value, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			if err := handle(value); err != nil {
+				return err
+			}
+			b = b[n:]
+			continue
+		}
+		if n = protowire.ConsumeFieldValue(fieldNum, typ, b); n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// decodeTFResponse decodes the PredictResponse of TensorFlow like proto.Unmarshal.
+func decodeTFResponse(body []byte, resp *tf_predict_protos.PredictResponse) error {
+	outputs := map[string]*tf_predict_protos.ArrayProto{}
+	err := forEachMessage(body, 1, func(entry []byte) error {
+		var name string
+		array := &wireArray{}
+		err := forEachMessage(entry, 1, func(key []byte) error {
+			name = string(key)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		err = forEachMessage(entry, 2, func(value []byte) error {
+			array, err = decodeArray(value)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		outputs[name] = array.tfArray()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	resp.Outputs = outputs
+	return nil
+}
+
+// decodeTorchResponse decodes the PredictResponse of PyTorch like proto.Unmarshal.
+func decodeTorchResponse(body []byte, resp *torch_predict_protos.PredictResponse) error {
+	var outputs []*torch_predict_protos.ArrayProto
+	err := forEachMessage(body, 1, func(value []byte) error {
+		array, err := decodeArray(value)
+		if err != nil {
+			return err
+		}
+		outputs = append(outputs, array.torchArray())
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	resp.Outputs = outputs
+	return nil
+}
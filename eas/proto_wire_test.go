@@ -0,0 +1,146 @@
+package eas
+
+import (
+	"io"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pai-eas/eas-golang-sdk/eas/types/tf_predict_protos"
+	"github.com/pai-eas/eas-golang-sdk/eas/types/torch_predict_protos"
+)
+
+func newBenchTFRequest() TFRequest {
+	req := TFRequest{}
+	req.SetSignatureName("serving_default")
+	req.AddFeedFloat32("images", []int64{1, 3, 224, 224}, make([]float32, 150528))
+	return req
+}
+
+func TestTFRequestProtoEncoding(t *testing.T) {
+	req := TFRequest{}
+	req.SetSignatureName("serving_default")
+	req.AddFeedFloat32("images", []int64{1, 2}, []float32{0.5, -1})
+	req.AddFeedInt32("ids", []int64{3}, []int32{-1, 0, 1 << 30})
+	req.AddFeedInt64("big", []int64{1}, []int64{-1 << 40})
+	req.AddFeedBool("flags", []int64{2}, []bool{true, false})
+	req.AddFeedString("words", []int64{2}, [][]byte{[]byte("a"), {}})
+	req.AddFeedFloat64("scalar", nil, []float64{3.5})
+	req.AddFetch("scores")
+
+	data, err := req.ToBytes()
+	assertNoError(t, err)
+	assertEqual(t, len(data), req.protoSize())
+	decoded := tf_predict_protos.PredictRequest{}
+	assertNoError(t, proto.Unmarshal(data, &decoded))
+	if !proto.Equal(&decoded, &req.RequestData) {
+		t.Fatalf("unexpected request decoded: %v", &decoded)
+	}
+
+	// responses are encoded in the same way as inputs of requests
+	outputs, err := proto.Marshal(&tf_predict_protos.PredictResponse{Outputs: req.RequestData.Inputs})
+	assertNoError(t, err)
+	resp := TFResponse{}
+	assertNoError(t, resp.unmarshal(outputs))
+	if !proto.Equal(&resp.Response, &tf_predict_protos.PredictResponse{Outputs: req.RequestData.Inputs}) {
+		t.Fatalf("unexpected response decoded: %v", &resp.Response)
+	}
+
+	// unpacked repeated fields are accepted as well
+	resp = TFResponse{}
+	assertNoError(t, resp.unmarshal([]byte{0x0a, 0x0f, 0x0a, 0x01, 'x', 0x12, 0x0a,
+		0x1d, 0x00, 0x00, 0x80, 0x3f, 0x1d, 0x00, 0x00, 0x00, 0x40}))
+	vals := resp.GetFloatVal("x")
+	if len(vals) != 2 || vals[0] != 1 || vals[1] != 2 {
+		t.Fatalf("unexpected unpacked floats: %v", vals)
+	}
+	if err = resp.unmarshal([]byte{0x0a, 0x05, 0x0a}); err == nil {
+		t.Fatal("truncated response should be rejected")
+	}
+}
+
+func TestTorchRequestProtoEncoding(t *testing.T) {
+	req := TorchRequest{}
+	req.AddFeedFloat32(0, []int64{2}, []float32{1, 2})
+	req.AddFeedInt64(1, []int64{1}, []int64{9})
+	req.AddFetch(0)
+	req.AddFetch(1)
+
+	data, err := req.ToBytes()
+	assertNoError(t, err)
+	decoded := torch_predict_protos.PredictRequest{}
+	assertNoError(t, proto.Unmarshal(data, &decoded))
+	if !proto.Equal(&decoded, &req.RequestData) {
+		t.Fatalf("unexpected request decoded: %v", &decoded)
+	}
+
+	outputs, err := proto.Marshal(&torch_predict_protos.PredictResponse{Outputs: req.RequestData.Inputs})
+	assertNoError(t, err)
+	resp := TorchResponse{}
+	assertNoError(t, resp.unmarshal(outputs))
+	if !proto.Equal(&resp.Response, &torch_predict_protos.PredictResponse{Outputs: req.RequestData.Inputs}) {
+		t.Fatalf("unexpected response decoded: %v", &resp.Response)
+	}
+}
+
+func BenchmarkTFRequestProtoMarshal(b *testing.B) {
+	req := newBenchTFRequest()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		data, _ := proto.Marshal(&req.RequestData)
+		b.SetBytes(int64(len(data)))
+	}
+}
+
+func BenchmarkTFRequestEncode(b *testing.B) {
+	req := newBenchTFRequest()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		data, release, _ := encodeRequest(req)
+		b.SetBytes(int64(len(data)))
+		release()
+	}
+}
+
+func BenchmarkTFResponseProtoUnmarshal(b *testing.B) {
+	req := newBenchTFRequest()
+	body, _ := proto.Marshal(&tf_predict_protos.PredictResponse{Outputs: req.RequestData.Inputs})
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		resp := tf_predict_protos.PredictResponse{}
+		proto.Unmarshal(body, &resp)
+	}
+}
+
+func BenchmarkTFResponseDecode(b *testing.B) {
+	req := newBenchTFRequest()
+	body, _ := proto.Marshal(&tf_predict_protos.PredictResponse{Outputs: req.RequestData.Inputs})
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		resp := TFResponse{}
+		resp.unmarshal(body)
+	}
+}
+
+func TestDecodeArrayTruncatedPacked(t *testing.T) {
+	// float_val of 5 bytes, and double_val of 9 bytes
+	for _, b := range [][]byte{{0x1a, 5, 0, 0, 128, 63, 0}, {0x22, 9, 0, 0, 0, 0, 0, 0, 240, 63, 0}} {
+		if _, err := decodeArray(b); err == nil {
+			t.Fatalf("expect error decoding %v", b)
+		}
+	}
+}
+
+func TestRequestBodyRelease(t *testing.T) {
+	released := false
+	body := newBytesBody([]byte("request"), "")
+	body.release = func() { released = true }
+	reader, err := body.open()
+	assertNoError(t, err)
+	// the transport may still read the body after the request is done
+	body.finish()
+	assertEqual(t, released, false)
+	assertNoError(t, reader.(io.Closer).Close())
+	assertEqual(t, released, true)
+}
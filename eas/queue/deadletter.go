@@ -0,0 +1,187 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pai-eas/eas-golang-sdk/eas"
+	"github.com/pai-eas/eas-golang-sdk/eas/types"
+)
+
+// Tags of frames moved into the dead-letter queue.
+const (
+	// TagAttempts is the number of times the frame failed.
+	TagAttempts = "deadletter.attempts"
+	// TagFailures is the JSON array of the last maxFailuresKept Failures, the reasons are truncated to
+	// maxReasonLength. Tags are sent in the URL, so the history is bounded.
+	TagFailures = "deadletter.failures"
+	// TagSourceIndex is the index of frame in the input queue.
+	TagSourceIndex = "deadletter.index"
+)
+
+const (
+	// maxReasonLength limits the reasons kept in the tags of dead letters.
+	maxReasonLength = 256
+	// maxFailuresKept limits the failures kept in the tags of dead letters, the earlier ones are
+	// dropped, while TagAttempts counts all of them.
+	maxFailuresKept = 5
+	// failureTTL is how long the failures of a frame are remembered since its last failure, frames may
+	// be redelivered to other workers and never come back.
+	failureTTL = time.Hour
+)
+
+// Failure is a failed attempt of handling a frame.
+type Failure struct {
+	Time   time.Time  `json:"time"`
+	Code   types.Code `json:"code"`
+	Reason string     `json:"reason"`
+}
+
+// DeadLetter is a frame moved into the dead-letter queue after it failed too many times.
+type DeadLetter struct {
+	// Frame is the frame in dead-letter queue, with the tags of original frame and dead letter.
+	Frame       types.DataFrame
+	Attempts    int
+	Failures    []Failure
+	SourceIndex uint64
+}
+
+// ParseDeadLetter parses the failure history from the tags of frame in dead-letter queue.
+func ParseDeadLetter(frame types.DataFrame) (DeadLetter, error) {
+	letter := DeadLetter{Frame: frame}
+	attempts, err := strconv.Atoi(frame.Tags.Get(TagAttempts))
+	if err != nil {
+		return letter, fmt.Errorf("frame %d is not a dead letter: invalid %s tag %q", frame.Index.Uint64(), TagAttempts, frame.Tags.Get(TagAttempts))
+	}
+	letter.Attempts = attempts
+	if failures := frame.Tags.Get(TagFailures); len(failures) > 0 {
+		if err = json.Unmarshal([]byte(failures), &letter.Failures); err != nil {
+			return letter, fmt.Errorf("frame %d: invalid %s tag: %v", frame.Index.Uint64(), TagFailures, err)
+		}
+	}
+	letter.SourceIndex, _ = strconv.ParseUint(frame.Tags.Get(TagSourceIndex), 10, 64)
+	return letter, nil
+}
+
+// OriginalTags returns the tags of frame before it was moved into dead-letter queue.
+func (d DeadLetter) OriginalTags() types.Tags {
+	tags := types.Tags{}
+	for key, val := range d.Frame.Tags {
+		if key != TagAttempts && key != TagFailures && key != TagSourceIndex {
+			tags[key] = val
+		}
+	}
+	return tags
+}
+
+// failureTracker counts the failures of frames by index, which is kept while frames are redelivered
+// after Negative. It is local to the worker, so a frame failing on n workers may be attempted up to n
+// times the max attempts before it is dead-lettered.
+type failureTracker struct {
+	mu       sync.Mutex
+	failures map[uint64][]Failure
+}
+
+func newFailureTracker() *failureTracker {
+	return &failureTracker{failures: map[uint64][]Failure{}}
+}
+
+// record adds a failure of frame and returns all its failures.
+func (t *failureTracker) record(index uint64, failure Failure) []Failure {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, failures := range t.failures {
+		if failure.Time.Sub(failures[len(failures)-1].Time) > failureTTL {
+			delete(t.failures, i)
+		}
+	}
+	if len(failure.Reason) > maxReasonLength {
+		failure.Reason = failure.Reason[:maxReasonLength]
+	}
+	t.failures[index] = append(t.failures[index], failure)
+	return t.failures[index]
+}
+
+func (t *failureTracker) forget(index uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.failures, index)
+}
+
+// deadLetter puts the frame with its last failures into the dead-letter queue.
+func deadLetter(ctx context.Context, dlq *eas.QueueClient, frame types.DataFrame, failures []Failure) error {
+	kept := failures
+	if len(kept) > maxFailuresKept {
+		kept = kept[len(kept)-maxFailuresKept:]
+	}
+	history, err := json.Marshal(kept)
+	if err != nil {
+		return err
+	}
+	tags := types.Tags{}
+	for key, val := range frame.Tags {
+		tags[key] = val
+	}
+	tags[TagAttempts] = strconv.Itoa(len(failures))
+	tags[TagFailures] = string(history)
+	tags[TagSourceIndex] = strconv.FormatUint(frame.Index.Uint64(), 10)
+	if _, _, err = dlq.Put(ctx, frame.Data, tags); err != nil {
+		return fmt.Errorf("put into dead-letter queue: %v", err)
+	}
+	return nil
+}
+
+// ReadDeadLetters returns up to limit dead letters from the index of dead-letter queue without
+// removing them, frames which are not dead letters are skipped.
+func ReadDeadLetters(ctx context.Context, dlq *eas.QueueClient, index uint64, limit int) ([]DeadLetter, error) {
+	frames, err := dlq.Get(ctx, index, limit, 0, false, types.Tags{})
+	if err != nil {
+		return nil, err
+	}
+	letters := make([]DeadLetter, 0, len(frames))
+	for _, frame := range frames {
+		if letter, err := ParseDeadLetter(frame); err == nil {
+			letters = append(letters, letter)
+		}
+	}
+	return letters, nil
+}
+
+// Requeue moves the dead letters accepted by filter, or all if filter is nil, from the dead-letter
+// queue back into target with their original tags, so that they are attempted again from scratch.
+// It returns the number of frames requeued.
+func Requeue(ctx context.Context, dlq, target *eas.QueueClient, filter func(DeadLetter) bool) (int, error) {
+	const batch = 100
+	requeued := 0
+	index := uint64(0)
+	for {
+		frames, err := dlq.Get(ctx, index, batch, 0, false, types.Tags{})
+		if err != nil {
+			return requeued, err
+		}
+		if len(frames) == 0 {
+			return requeued, nil
+		}
+		for _, frame := range frames {
+			letter, err := ParseDeadLetter(frame)
+			if err != nil || (filter != nil && !filter(letter)) {
+				continue
+			}
+			if _, _, err = target.Put(ctx, frame.Data, letter.OriginalTags()); err != nil {
+				return requeued, fmt.Errorf("requeue frame %d: %v", frame.Index.Uint64(), err)
+			}
+			if err = dlq.Del(ctx, frame.Index.Uint64()); err != nil {
+				return requeued, fmt.Errorf("delete requeued frame %d: %v", frame.Index.Uint64(), err)
+			}
+			requeued++
+		}
+		index = types.LargestIndex(frames).Uint64() + 1
+		if len(frames) < batch {
+			return requeued, nil
+		}
+	}
+}
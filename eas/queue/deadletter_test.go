@@ -0,0 +1,120 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pai-eas/eas-golang-sdk/eas/internal/queuetest"
+	"github.com/pai-eas/eas-golang-sdk/eas/types"
+)
+
+func TestDeadLetter(t *testing.T) {
+	input, dlq := newServer(t), newServer(t)
+	input.Put([]byte("poison"), types.Tags{"requestId": "r1", "model": "m"})
+	input.Put([]byte("ok"), nil)
+
+	worker, err := NewWorker(newQueueClient(t, input), func(ctx context.Context, frame types.DataFrame) ([]byte, error) {
+		if string(frame.Data) == "poison" {
+			return nil, &CodeError{Code: "Invalid", Err: errors.New("can not parse")}
+		}
+		return frame.Data, nil
+	}, WithMaxAttempts(3), WithDeadLetter(newQueueClient(t, dlq)), WithSignals(),
+		WithErrorHandler(func(types.DataFrame, error) {}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- worker.Run(ctx) }()
+	ok := queuetest.WaitFor(5*time.Second, func() bool { return len(input.Committed()) == 2 })
+	cancel()
+	<-done
+	if !ok {
+		t.Fatalf("committed %v, negatives %v", input.Committed(), input.Negatives())
+	}
+	if negatives := input.Negatives(); len(negatives) != 2 || negatives[0].Code != "Invalid" {
+		t.Fatalf("unexpected negatives: %v", negatives)
+	}
+
+	letters, err := ReadDeadLetters(context.Background(), newQueueClient(t, dlq), 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(letters) != 1 {
+		t.Fatalf("unexpected dead letters: %v", dlq)
+	}
+	letter := letters[0]
+	if string(letter.Frame.Data) != "poison" || letter.Attempts != 3 || len(letter.Failures) != 3 || letter.SourceIndex != 1 {
+		t.Fatalf("unexpected dead letter: %+v", letter)
+	}
+	if letter.Failures[2].Code != "Invalid" || letter.Failures[2].Reason != "Invalid: can not parse" {
+		t.Fatalf("unexpected failure: %+v", letter.Failures[2])
+	}
+
+	// requeue into the input queue with the original tags
+	requeued, err := Requeue(context.Background(), newQueueClient(t, dlq), newQueueClient(t, input), func(letter DeadLetter) bool {
+		return letter.Frame.Tags.Get("model") == "m"
+	})
+	if err != nil || requeued != 1 {
+		t.Fatalf("requeue: %d, %v", requeued, err)
+	}
+	if len(dlq.Frames()) != 0 {
+		t.Fatalf("dead letters are not removed: %v", dlq)
+	}
+	frames := input.Frames()
+	if len(frames) != 1 || !reflect.DeepEqual(frames[0].Tags, types.Tags{"requestId": "r1", "model": "m"}) {
+		t.Fatalf("unexpected input queue: %v", input)
+	}
+}
+
+func TestDropWithoutDeadLetter(t *testing.T) {
+	input := newServer(t)
+	input.Put([]byte("poison"), nil)
+
+	dropped := make(chan error, 10)
+	worker, err := NewWorker(newQueueClient(t, input), func(ctx context.Context, frame types.DataFrame) ([]byte, error) {
+		return nil, errors.New("failed")
+	}, WithMaxAttempts(2), WithSignals(), WithErrorHandler(func(frame types.DataFrame, err error) {
+		dropped <- err
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- worker.Run(ctx) }()
+	ok := queuetest.WaitFor(5*time.Second, func() bool { return len(input.Committed()) == 1 })
+	cancel()
+	<-done
+	if !ok || len(input.Negatives()) != 1 {
+		t.Fatalf("committed %v, negatives %v", input.Committed(), input.Negatives())
+	}
+	// two failures and the drop are reported
+	if len(dropped) != 3 {
+		t.Fatalf("unexpected errors reported: %d", len(dropped))
+	}
+}
+
+func TestDeadLetterKeepsLastFailures(t *testing.T) {
+	dlq := newServer(t)
+	var failures []Failure
+	for i := 0; i < maxFailuresKept+3; i++ {
+		failures = append(failures, Failure{Time: time.Now(), Code: types.Code(fmt.Sprint(i)), Reason: "failed"})
+	}
+	frame := types.DataFrame{Index: types.FromUint64(7), Data: []byte("poison")}
+	if err := deadLetter(context.Background(), newQueueClient(t, dlq), frame, failures); err != nil {
+		t.Fatal(err)
+	}
+	letter, err := ParseDeadLetter(dlq.Frames()[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if letter.Attempts != len(failures) || len(letter.Failures) != maxFailuresKept ||
+		letter.Failures[0].Code != failures[3].Code || letter.Failures[maxFailuresKept-1].Code != failures[len(failures)-1].Code {
+		t.Fatalf("unexpected dead letter: %+v", letter)
+	}
+}
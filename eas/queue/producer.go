@@ -0,0 +1,223 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pai-eas/eas-golang-sdk/eas"
+	"github.com/pai-eas/eas-golang-sdk/eas/types"
+)
+
+// Defaults of Producer.
+const (
+	DefaultBatchSize   = 100
+	DefaultLinger      = 10 * time.Millisecond
+	DefaultMaxInFlight = 4
+)
+
+// produceTimeout limits a batch put by Producer, which is sent in background.
+const produceTimeout = 30 * time.Second
+
+// ErrProducerClosed is returned by Send once the Producer is closed.
+var ErrProducerClosed = errors.New("producer is closed")
+
+// Callback reports the outcome of a message sent by Producer, with its index and request id in queue
+// if err is nil. Callbacks are called from the goroutines of Producer and should not block long.
+type Callback func(index uint64, requestId string, err error)
+
+type producerOptions struct {
+	batchSize   int
+	linger      time.Duration
+	maxInFlight int
+}
+
+// ProducerOption customizes Producer.
+type ProducerOption func(*producerOptions)
+
+// WithBatchSize sets the number of messages buffered before they are put as a batch, 100 by default.
+func WithBatchSize(size int) ProducerOption {
+	return func(o *producerOptions) {
+		o.batchSize = size
+	}
+}
+
+// WithLinger sets how long the first buffered message waits for more before the buffer is put,
+// 10ms by default.
+func WithLinger(linger time.Duration) ProducerOption {
+	return func(o *producerOptions) {
+		o.linger = linger
+	}
+}
+
+// WithMaxInFlight sets the number of batches being put at the same time, 4 by default. Send blocks
+// when the buffer is full and so many batches are in flight.
+func WithMaxInFlight(batches int) ProducerOption {
+	return func(o *producerOptions) {
+		o.maxInFlight = batches
+	}
+}
+
+type message struct {
+	frame    types.DataFrame
+	callback Callback
+}
+
+// Producer buffers messages and puts them into queue in batches by QueueClient.PutBatch, the buffer is
+// put once it reaches the batch size or lingers long enough. Messages of different batches may be put
+// out of order.
+type Producer struct {
+	queue  *eas.QueueClient
+	opts   producerOptions
+	tokens chan struct{}
+
+	mu       sync.Mutex
+	idle     *sync.Cond
+	buffer   []message
+	timer    *time.Timer
+	inFlight int
+	closed   bool
+}
+
+// NewProducer creates a producer putting messages into the queue.
+func NewProducer(queue *eas.QueueClient, opts ...ProducerOption) (*Producer, error) {
+	o := producerOptions{batchSize: DefaultBatchSize, linger: DefaultLinger, maxInFlight: DefaultMaxInFlight}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.batchSize <= 0 || o.maxInFlight <= 0 || o.linger <= 0 {
+		return nil, fmt.Errorf("batch size, linger and max in flight should be positive")
+	}
+	p := &Producer{queue: queue, opts: o, tokens: make(chan struct{}, o.maxInFlight)}
+	p.idle = sync.NewCond(&p.mu)
+	return p, nil
+}
+
+// Send buffers the data with tags to be put into queue, callback is called with the outcome and may be
+// nil. It blocks only when the buffer is full and waits for a batch in flight to finish, or ctx is done.
+func (p *Producer) Send(ctx context.Context, data []byte, tags types.Tags, callback Callback) error {
+	if err := tags.Validate(); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return ErrProducerClosed
+	}
+	p.buffer = append(p.buffer, message{frame: types.DataFrame{Data: data, Tags: tags}, callback: callback})
+	var batch []message
+	if len(p.buffer) >= p.opts.batchSize {
+		batch = p.take()
+	} else if len(p.buffer) == 1 {
+		p.timer = time.AfterFunc(p.opts.linger, p.linger)
+	}
+	p.mu.Unlock()
+	if batch != nil {
+		return p.dispatch(ctx, batch)
+	}
+	return nil
+}
+
+// Flush puts the buffered messages, and blocks until all the batches in flight are finished.
+func (p *Producer) Flush(ctx context.Context) error {
+	p.mu.Lock()
+	batch := p.take()
+	p.mu.Unlock()
+	if batch != nil {
+		if err := p.dispatch(ctx, batch); err != nil {
+			return err
+		}
+	}
+	done := make(chan struct{})
+	go func() {
+		p.mu.Lock()
+		for p.inFlight > 0 {
+			p.idle.Wait()
+		}
+		p.mu.Unlock()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close flushes the producer, messages can not be sent after it is closed.
+func (p *Producer) Close(ctx context.Context) error {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+	return p.Flush(ctx)
+}
+
+// take returns the buffered messages and resets the buffer, p.mu must be held. The batch is counted in
+// flight at once, so Flush never misses a batch taken but not dispatched yet.
+func (p *Producer) take() []message {
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+	if len(p.buffer) == 0 {
+		return nil
+	}
+	batch := p.buffer
+	p.buffer = nil
+	p.inFlight++
+	return batch
+}
+
+func (p *Producer) linger() {
+	p.mu.Lock()
+	batch := p.take()
+	p.mu.Unlock()
+	if batch != nil {
+		p.dispatch(context.Background(), batch)
+	}
+}
+
+// dispatch puts the batch taken in background once the number of batches in flight is below the limit.
+func (p *Producer) dispatch(ctx context.Context, batch []message) error {
+	select {
+	case p.tokens <- struct{}{}:
+	case <-ctx.Done():
+		p.finish(batch, nil, ctx.Err())
+		return ctx.Err()
+	}
+	go func() {
+		defer func() { <-p.tokens }()
+		ctx, cancel := context.WithTimeout(context.Background(), produceTimeout)
+		defer cancel()
+		frames := make([]types.DataFrame, len(batch))
+		for i := range batch {
+			frames[i] = batch[i].frame
+		}
+		results, _ := p.queue.PutBatch(ctx, frames)
+		p.finish(batch, results, nil)
+	}()
+	return nil
+}
+
+// finish reports the outcome of batch by results, or err if it is not put at all.
+func (p *Producer) finish(batch []message, results []eas.PutResult, err error) {
+	for i, msg := range batch {
+		if msg.callback == nil {
+			continue
+		}
+		if err != nil {
+			msg.callback(0, "", err)
+		} else {
+			msg.callback(results[i].Index, results[i].RequestId, results[i].Err)
+		}
+	}
+	p.mu.Lock()
+	p.inFlight--
+	if p.inFlight == 0 {
+		p.idle.Broadcast()
+	}
+	p.mu.Unlock()
+}
@@ -0,0 +1,123 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pai-eas/eas-golang-sdk/eas/types"
+)
+
+type outcome struct {
+	index     uint64
+	requestId string
+	err       error
+}
+
+func TestProducer(t *testing.T) {
+	server := newServer(t)
+	server.Attributes[types.MaxPayloadBytes] = "8"
+	producer, err := NewProducer(newQueueClient(t, server), WithBatchSize(3), WithLinger(time.Hour), WithMaxInFlight(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	outcomes := map[string]outcome{}
+	for _, data := range []string{"a", "b", "c", "d", "too large data", "f", "g"} {
+		data := data
+		err = producer.Send(ctx, []byte(data), types.Tags{"k": data}, func(index uint64, requestId string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			outcomes[data] = outcome{index, requestId, err}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the last message is buffered until flushed, as the linger is long
+	if err = producer.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(outcomes) != 7 || server.Puts() != 6 {
+		t.Fatalf("unexpected outcomes %v of %d puts", outcomes, server.Puts())
+	}
+	indexes := map[uint64]bool{}
+	for data, o := range outcomes {
+		if data == "too large data" {
+			if o.err == nil {
+				t.Fatal("oversized message should fail")
+			}
+			continue
+		}
+		if o.err != nil || o.index == 0 || len(o.requestId) == 0 || indexes[o.index] {
+			t.Fatalf("unexpected outcome of %s: %+v", data, o)
+		}
+		indexes[o.index] = true
+	}
+	for _, frame := range server.Frames() {
+		if frame.Tags["k"] != string(frame.Data) {
+			t.Fatalf("unexpected frame: %v", frame)
+		}
+	}
+	if err = producer.Send(ctx, []byte("h"), nil, nil); err != ErrProducerClosed {
+		t.Fatalf("expect ErrProducerClosed, got %v", err)
+	}
+}
+
+func TestProducerLinger(t *testing.T) {
+	server := newServer(t)
+	producer, err := NewProducer(newQueueClient(t, server), WithLinger(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan outcome, 1)
+	err = producer.Send(context.Background(), []byte("a"), nil, func(index uint64, requestId string, err error) {
+		done <- outcome{index, requestId, err}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case o := <-done:
+		if o.err != nil || o.index != 1 {
+			t.Fatalf("unexpected outcome: %+v", o)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("message lingers too long")
+	}
+}
+
+func TestProducerCloseRacingLinger(t *testing.T) {
+	server := newServer(t)
+	client := newQueueClient(t, server)
+	ctx := context.Background()
+	for i := 0; i < 100; i++ {
+		producer, err := NewProducer(client, WithLinger(time.Duration(i%10)*100*time.Microsecond+time.Microsecond))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var mu sync.Mutex
+		acked := false
+		err = producer.Send(ctx, []byte("data"), nil, func(index uint64, requestId string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			acked = err == nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Duration(i%7) * 100 * time.Microsecond)
+		// the batch taken by the linger timer is waited for as well
+		if err = producer.Close(ctx); err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		if !acked {
+			t.Fatalf("Close returned before the message is acknowledged, attempt %d", i)
+		}
+		mu.Unlock()
+	}
+}
@@ -0,0 +1,376 @@
+// Package queue builds consumers of EAS queue service on top of eas.QueueClient.
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pai-eas/eas-golang-sdk/eas"
+	"github.com/pai-eas/eas-golang-sdk/eas/types"
+)
+
+// Defaults of Worker.
+const (
+	DefaultConcurrency  = 1
+	DefaultDrainTimeout = 30 * time.Second
+)
+
+// CodeFailed is the code of Negative for frames failed by handler, unless the error is a *CodeError.
+const CodeFailed types.Code = "HandlerFailed"
+
+// CodeUnreadable is the failure code of frames failed to be received, e.g. their blobs are missing or
+// they can not be decrypted. They are not passed to handler, and fail the same way at every delivery,
+// so they are moved into the dead-letter queue, or dropped without one, at the first failure.
+const CodeUnreadable types.Code = "FrameUnreadable"
+
+// ackTimeout limits the requests acknowledging frames, which are sent during shutdown as well.
+const ackTimeout = 10 * time.Second
+
+// Handler processes a frame of input queue, the result is put into the sink queue if there is one.
+type Handler func(ctx context.Context, frame types.DataFrame) ([]byte, error)
+
+// CodeError makes the frame failed by handler negatively acknowledged with Code.
+type CodeError struct {
+	Code types.Code
+	Err  error
+}
+
+func (e *CodeError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Code, e.Err)
+}
+
+func (e *CodeError) Unwrap() error {
+	return e.Err
+}
+
+type options struct {
+	concurrency  int
+	window       uint64
+	sink         *eas.QueueClient
+	drainTimeout time.Duration
+	signals      []os.Signal
+	onError      func(frame types.DataFrame, err error)
+	maxAttempts  int
+	deadLetter   *eas.QueueClient
+}
+
+// Option customizes Worker.
+type Option func(*options)
+
+// WithConcurrency sets the number of frames handled concurrently, 1 by default.
+func WithConcurrency(concurrency int) Option {
+	return func(o *options) {
+		o.concurrency = concurrency
+	}
+}
+
+// WithWindow sets the window of watcher, which is the number of uncommitted frames delivered to the
+// worker, the same as concurrency by default.
+func WithWindow(window uint64) Option {
+	return func(o *options) {
+		o.window = window
+	}
+}
+
+// WithSink puts the results of handler into the sink queue, with the tags of input frames.
+func WithSink(sink *eas.QueueClient) Option {
+	return func(o *options) {
+		o.sink = sink
+	}
+}
+
+// WithDrainTimeout sets how long the frames in flight are waited for on shutdown, before the context of
+// handlers is cancelled, 30 seconds by default.
+func WithDrainTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.drainTimeout = timeout
+	}
+}
+
+// WithSignals sets the signals shutting down the worker, SIGTERM and SIGINT by default, no signal is
+// handled if it is called without signals.
+func WithSignals(signals ...os.Signal) Option {
+	return func(o *options) {
+		o.signals = signals
+	}
+}
+
+// WithErrorHandler sets the function called with errors failing frames, or acknowledging them. Errors
+// are written by the standard logger by default.
+func WithErrorHandler(onError func(frame types.DataFrame, err error)) Option {
+	return func(o *options) {
+		o.onError = onError
+	}
+}
+
+// WithMaxAttempts sets how many times a frame can fail before it is moved into the dead-letter queue set
+// by WithDeadLetter, or dropped without dead-letter queue. Failures are counted by the worker, frames
+// failed by handler are retried without limit by default, unreadable ones are not, see CodeUnreadable.
+func WithMaxAttempts(attempts int) Option {
+	return func(o *options) {
+		o.maxAttempts = attempts
+	}
+}
+
+// WithDeadLetter sets the queue where frames failed max attempts are put, with their failure history
+// in tags, see ParseDeadLetter and Requeue.
+func WithDeadLetter(dlq *eas.QueueClient) Option {
+	return func(o *options) {
+		o.deadLetter = dlq
+	}
+}
+
+// Worker watches the input queue and processes frames by Handler concurrently. Frames are committed
+// once they are handled and their results are put into sink queue, otherwise they are negatively
+// acknowledged with the reason. On shutdown, the frames in flight are drained, and the ones left
+// unprocessed are negatively acknowledged with types.Shutdown to be delivered to other workers.
+type Worker struct {
+	input    *eas.QueueClient
+	handler  Handler
+	opts     options
+	failures *failureTracker
+}
+
+// NewWorker creates a worker consuming the input queue.
+func NewWorker(input *eas.QueueClient, handler Handler, opts ...Option) (*Worker, error) {
+	o := options{
+		concurrency:  DefaultConcurrency,
+		drainTimeout: DefaultDrainTimeout,
+		signals:      []os.Signal{syscall.SIGTERM, os.Interrupt},
+		onError: func(frame types.DataFrame, err error) {
+			log.Printf("queue worker: frame %d: %v", frame.Index.Uint64(), err)
+		},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if input == nil || handler == nil {
+		return nil, fmt.Errorf("input queue and handler are required")
+	}
+	if o.concurrency <= 0 {
+		return nil, fmt.Errorf("concurrency should be positive")
+	}
+	if o.maxAttempts < 0 {
+		return nil, fmt.Errorf("max attempts should not be negative")
+	}
+	if o.window == 0 {
+		o.window = uint64(o.concurrency)
+	}
+	return &Worker{input: input, handler: handler, opts: o, failures: newFailureTracker()}, nil
+}
+
+// Run processes frames until ctx is done or a shutdown signal is received, and then drains the frames
+// in flight. It returns nil once shut down, or the error watching the input queue at first.
+func (w *Worker) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if len(w.opts.signals) > 0 {
+		ch := make(chan os.Signal, 1)
+		signal.Notify(ch, w.opts.signals...)
+		defer signal.Stop(ch)
+		go func() {
+			select {
+			case <-ch:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+	watcher, err := w.input.Watch(ctx, 0, w.opts.window, false, false)
+	if err != nil {
+		return err
+	}
+
+	// handlers are not cancelled with ctx but after the drain timeout
+	handlerCtx, cancelHandlers := context.WithCancel(detach(ctx))
+	defer cancelHandlers()
+	tokens := make(chan struct{}, w.opts.concurrency)
+	var wg sync.WaitGroup
+	var unprocessed []types.DataFrame
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case frame, ok := <-watcher.FrameChan():
+			if !ok {
+				if watcher = w.rewatch(ctx); watcher == nil {
+					break loop
+				}
+				continue
+			}
+			if !isFrame(frame) {
+				continue
+			}
+			select {
+			case tokens <- struct{}{}:
+			case <-ctx.Done():
+				unprocessed = append(unprocessed, frame)
+				break loop
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-tokens }()
+				w.process(handlerCtx, frame)
+			}()
+		}
+	}
+
+	if watcher != nil {
+		watcher.Close()
+		// frames delivered but not handled yet
+		for frame := range watcher.FrameChan() {
+			if isFrame(frame) {
+				unprocessed = append(unprocessed, frame)
+			}
+		}
+	}
+	if len(unprocessed) > 0 {
+		w.negative(unprocessed, types.Shutdown, "worker is shutting down")
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(w.opts.drainTimeout):
+		cancelHandlers()
+		<-drained
+	}
+	return nil
+}
+
+// isFrame tells frames of queue from the error messages of watcher, frames failed to be received have
+// their indexes with the error in Message.
+func isFrame(frame types.DataFrame) bool {
+	return frame.Index != 0 || len(frame.Message) == 0
+}
+
+func (w *Worker) rewatch(ctx context.Context) types.Watcher {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Second):
+		}
+		watcher, err := w.input.Watch(ctx, 0, w.opts.window, false, false)
+		if err == nil {
+			return watcher
+		}
+		w.opts.onError(types.DataFrame{}, fmt.Errorf("watch input queue: %v", err))
+	}
+}
+
+func (w *Worker) process(ctx context.Context, frame types.DataFrame) {
+	var result []byte
+	var err error
+	unreadable := len(frame.Message) > 0
+	if unreadable {
+		err = &CodeError{Code: CodeUnreadable, Err: errors.New(frame.Message)}
+	} else {
+		result, err = w.handler(ctx, frame)
+	}
+	if err == nil && w.opts.sink != nil {
+		err = w.putResult(frame, result)
+	}
+	if err == nil {
+		w.failures.forget(frame.Index.Uint64())
+		w.commit(frame)
+		return
+	}
+	if ctx.Err() != nil {
+		// cancelled after the drain timeout
+		w.negative([]types.DataFrame{frame}, types.Shutdown, err.Error())
+		return
+	}
+	w.opts.onError(frame, err)
+	code := CodeFailed
+	if codeErr, ok := err.(*CodeError); ok {
+		code = codeErr.Code
+	}
+	failures := w.failures.record(frame.Index.Uint64(), Failure{Time: time.Now(), Code: code, Reason: err.Error()})
+	if unreadable || w.opts.maxAttempts > 0 && len(failures) >= w.opts.maxAttempts {
+		w.giveUp(frame, failures)
+		return
+	}
+	w.negative([]types.DataFrame{frame}, code, err.Error())
+}
+
+// giveUp moves the frame failed max attempts into dead-letter queue, or drops it without one.
+func (w *Worker) giveUp(frame types.DataFrame, failures []Failure) {
+	last := failures[len(failures)-1]
+	if w.opts.deadLetter != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), ackTimeout)
+		defer cancel()
+		if err := deadLetter(ctx, w.opts.deadLetter, frame, failures); err != nil {
+			// keep the frame in input queue to be dead-lettered at the next failure
+			w.opts.onError(frame, err)
+			w.negative([]types.DataFrame{frame}, last.Code, last.Reason)
+			return
+		}
+	} else {
+		w.opts.onError(frame, fmt.Errorf("dropped after %d failed attempts", len(failures)))
+	}
+	w.failures.forget(frame.Index.Uint64())
+	w.commit(frame)
+}
+
+func (w *Worker) commit(frame types.DataFrame) {
+	ctx, cancel := context.WithTimeout(context.Background(), ackTimeout)
+	defer cancel()
+	if err := w.input.Commit(ctx, frame.Index.Uint64()); err != nil {
+		w.opts.onError(frame, fmt.Errorf("commit: %v", err))
+	}
+}
+
+func (w *Worker) putResult(frame types.DataFrame, result []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), ackTimeout)
+	defer cancel()
+	tags := make(types.Tags, len(frame.Tags))
+	for key, val := range frame.Tags {
+		tags[key] = val
+	}
+	if _, _, err := w.opts.sink.Put(ctx, result, tags); err != nil {
+		return fmt.Errorf("put result into sink: %v", err)
+	}
+	return nil
+}
+
+func (w *Worker) negative(frames []types.DataFrame, code types.Code, reason string) {
+	ctx, cancel := context.WithTimeout(context.Background(), ackTimeout)
+	defer cancel()
+	indexes := make([]uint64, len(frames))
+	for i, frame := range frames {
+		indexes[i] = frame.Index.Uint64()
+	}
+	if err := w.input.Negative(ctx, code, reason, indexes...); err != nil {
+		for _, frame := range frames {
+			w.opts.onError(frame, fmt.Errorf("negative: %v", err))
+		}
+	}
+}
+
+// detachedContext keeps the values of parent without its cancellation.
+type detachedContext struct {
+	context.Context
+	parent context.Context
+}
+
+func detach(parent context.Context) context.Context {
+	return detachedContext{Context: context.Background(), parent: parent}
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
@@ -0,0 +1,200 @@
+package queue
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pai-eas/eas-golang-sdk/eas"
+	"github.com/pai-eas/eas-golang-sdk/eas/internal/queuetest"
+	"github.com/pai-eas/eas-golang-sdk/eas/types"
+)
+
+func newQueueClient(t *testing.T, server *queuetest.Server) *eas.QueueClient {
+	client, err := eas.NewQueueClient(server.URL, "", "token", eas.WithBasePath(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.WebsocketWatch = false
+	return client
+}
+
+func newServer(t *testing.T) *queuetest.Server {
+	server := queuetest.NewServer()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestWorkerCommitAndNegative(t *testing.T) {
+	input, sink := newServer(t), newServer(t)
+	input.Put([]byte("a"), types.Tags{"requestId": "r1"})
+	input.Put([]byte("bad"), nil)
+	input.Put([]byte("b"), nil)
+	input.Put([]byte("invalid"), nil)
+
+	var mu sync.Mutex
+	var failed []string
+	worker, err := NewWorker(newQueueClient(t, input), func(ctx context.Context, frame types.DataFrame) ([]byte, error) {
+		switch string(frame.Data) {
+		case "bad":
+			return nil, errors.New("bad input")
+		case "invalid":
+			return nil, &CodeError{Code: "Invalid", Err: errors.New("invalid input")}
+		}
+		return bytes.ToUpper(frame.Data), nil
+	}, WithConcurrency(2), WithSink(newQueueClient(t, sink)), WithSignals(), WithErrorHandler(func(frame types.DataFrame, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		failed = append(failed, string(frame.Data))
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- worker.Run(ctx) }()
+
+	ok := queuetest.WaitFor(5*time.Second, func() bool {
+		return len(input.Committed()) == 2 && len(input.Negatives()) >= 2
+	})
+	cancel()
+	if err = <-done; err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatalf("committed %v, negatives %v", input.Committed(), input.Negatives())
+	}
+	if committed := queuetest.SortIndexes(input.Committed()); !reflect.DeepEqual(committed, []uint64{1, 3}) {
+		t.Fatalf("unexpected committed indexes: %v", committed)
+	}
+	codes := map[uint64]string{}
+	for _, negative := range input.Negatives() {
+		if negative.Code != types.Shutdown.String() {
+			codes[negative.Indexes[0]] = negative.Code
+		}
+	}
+	if !reflect.DeepEqual(codes, map[uint64]string{2: CodeFailed.String(), 4: "Invalid"}) {
+		t.Fatalf("unexpected negatives: %v", input.Negatives())
+	}
+	results := map[string]string{}
+	for _, frame := range sink.Frames() {
+		results[string(frame.Data)] = frame.Tags["requestId"]
+	}
+	if !reflect.DeepEqual(results, map[string]string{"A": "r1", "B": "req-3"}) {
+		t.Fatalf("unexpected results: %v", sink)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(failed) < 2 {
+		t.Fatalf("unexpected failures: %v", failed)
+	}
+}
+
+func TestWorkerShutdown(t *testing.T) {
+	input := newServer(t)
+	for _, data := range []string{"a", "b", "c"} {
+		input.Put([]byte(data), nil)
+	}
+
+	started := make(chan struct{}, 3)
+	worker, err := NewWorker(newQueueClient(t, input), func(ctx context.Context, frame types.DataFrame) ([]byte, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}, WithWindow(3), WithDrainTimeout(50*time.Millisecond), WithSignals())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- worker.Run(ctx) }()
+	<-started
+	// wait for the other frames to be delivered
+	queuetest.WaitFor(time.Second, func() bool { return len(input.Frames()) == 3 })
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	if err = <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	var indexes []uint64
+	for _, negative := range input.Negatives() {
+		if negative.Code != types.Shutdown.String() {
+			t.Fatalf("unexpected negative: %v", negative)
+		}
+		indexes = append(indexes, negative.Indexes...)
+	}
+	// the frame in flight is cancelled after the drain timeout, the others are never handled
+	if !reflect.DeepEqual(queuetest.SortIndexes(indexes), []uint64{1, 2, 3}) {
+		t.Fatalf("unexpected negatives: %v", input.Negatives())
+	}
+	if len(input.Committed()) != 0 {
+		t.Fatalf("unexpected committed: %v", input.Committed())
+	}
+}
+
+func TestWorkerUnreadableFrame(t *testing.T) {
+	store, err := eas.NewLocalBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	newClient := func(server *queuetest.Server) *eas.QueueClient {
+		client, err := eas.NewQueueClient(server.URL, "", "token", eas.WithBasePath(""), eas.WithClaimCheck(store, 1024))
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.WebsocketWatch = false
+		return client
+	}
+	run := func(input *queuetest.Server, opts ...Option) (handled int) {
+		t.Helper()
+		var mu sync.Mutex
+		worker, err := NewWorker(newClient(input), func(ctx context.Context, frame types.DataFrame) ([]byte, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			handled++
+			return nil, nil
+		}, append([]Option{WithSignals(), WithErrorHandler(func(types.DataFrame, error) {})}, opts...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() { done <- worker.Run(ctx) }()
+		ok := queuetest.WaitFor(5*time.Second, func() bool { return len(input.Committed()) == 1 })
+		cancel()
+		<-done
+		if !ok {
+			t.Fatalf("committed %v, negatives %v", input.Committed(), input.Negatives())
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return handled
+	}
+
+	// unreadable frames are dropped at the first failure without limit of attempts
+	input := newServer(t)
+	input.Put(nil, types.Tags{eas.BlobKeyTag: "missing"})
+	if handled := run(input); handled != 0 || len(input.Negatives()) != 0 {
+		t.Fatalf("handled %d, negatives %v", handled, input.Negatives())
+	}
+
+	// or moved into the dead-letter queue as they are received
+	input, dlq := newServer(t), newServer(t)
+	input.Put(nil, types.Tags{eas.BlobKeyTag: "missing"})
+	if handled := run(input, WithMaxAttempts(3), WithDeadLetter(newQueueClient(t, dlq))); handled != 0 || len(input.Negatives()) != 0 {
+		t.Fatalf("handled %d, negatives %v", handled, input.Negatives())
+	}
+	letters, err := ReadDeadLetters(context.Background(), newQueueClient(t, dlq), 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(letters) != 1 || letters[0].Attempts != 1 || letters[0].Failures[0].Code != CodeUnreadable ||
+		letters[0].Frame.Tags.Get(eas.BlobKeyTag) != "missing" {
+		t.Fatalf("unexpected dead letters: %+v", letters)
+	}
+}
@@ -25,6 +25,9 @@ const (
 	DefaultBasePath = "/api/predict"
 
 	DefaultGroupName = "eas"
+
+	// DefaultBatchConcurrency is the number of requests PutBatch sends concurrently by default.
+	DefaultBatchConcurrency = 16
 )
 
 type QueueUser struct {
@@ -64,18 +67,53 @@ type QueueClient struct {
 
 	WebsocketWatch bool
 
-	once sync.Once
-	attr types.Attributes
-	// codecs for data frame and attributes.
+	// attrMu guards attr, as the client is used concurrently, e.g. by queue.Worker.
+	// It is not held while attributes are fetched, concurrent callers wait for the same attrCall.
+	attrMu   sync.Mutex
+	attr     types.Attributes
+	attrCall *attrCall
+	// codecs for data frame and attributes, chosen by WithMediaType.
 	DCodec types.DataFrameCodec
 	ACodec types.AttributesCodec
+
+	// compression of data put into queue, and the minimum size to be compressed.
+	compression          string
+	compressionThreshold int
+
+	batchConcurrency int
+
+	// chunking splits payloads exceeding the limit of queue, chunks holds the chunks of reassembled frames.
+	chunking bool
+	chunks   chunkGroups
+
+	// payloads larger than blobThreshold are offloaded into blobStore, blobs holds the keys of received frames.
+	blobStore     BlobStore
+	blobThreshold int
+	blobs         blobKeys
+	// onBlobError is called with the blobs failed to be deleted.
+	onBlobError func(key string, err error)
+
+	// keyProvider provides the keys encrypting payloads, nil if encryption is disabled.
+	keyProvider KeyProvider
+	// allowPlaintext delivers the frames not encrypted as they are instead of failing them.
+	allowPlaintext bool
 }
 
 type queueOptions struct {
-	extraHeaders map[string]string
-	basePath     string
-	uid          string
-	gid          string
+	extraHeaders         map[string]string
+	basePath             string
+	uid                  string
+	gid                  string
+	compression          string
+	compressionThreshold int
+	batchConcurrency     int
+	chunking             bool
+	blobStore            BlobStore
+	blobThreshold        int
+	onBlobError          func(key string, err error)
+	keyProvider          KeyProvider
+	allowPlaintext       bool
+	mediaTypes           []string
 }
 
 type QueueOption func(*queueOptions)
@@ -104,11 +142,47 @@ func WithGroupId(gid string) QueueOption {
 	}
 }
 
+// WithCompression compresses the payloads put into queue with "gzip" or "zstd" once their size
+// reaches the threshold, the responses of Get are requested and decoded in compressed form as well.
+// With encryption enabled, payloads are compressed before they are encrypted instead.
+func WithCompression(compression string, threshold int) QueueOption {
+	return func(o *queueOptions) {
+		o.compression = compression
+		o.compressionThreshold = threshold
+	}
+}
+
+// WithMediaType sets the media types of the frames and attributes received from queue in preference,
+// e.g. types.ContentTypeJSON, the first one with a registered codec is used, protobuf by default.
+func WithMediaType(mediaTypes ...string) QueueOption {
+	return func(o *queueOptions) {
+		o.mediaTypes = mediaTypes
+	}
+}
+
+// WithBatchConcurrency sets the number of requests PutBatch sends concurrently, connections to the
+// queue service are kept alive for them.
+func WithBatchConcurrency(concurrency int) QueueOption {
+	return func(o *queueOptions) {
+		o.batchConcurrency = concurrency
+	}
+}
+
 func NewQueueClient(endpoint, queueName, token string, opts ...QueueOption) (*QueueClient, error) {
-	queueOpt := &queueOptions{basePath: DefaultBasePath}
+	queueOpt := &queueOptions{basePath: DefaultBasePath, compressionThreshold: DefaultCompressionThreshold, batchConcurrency: DefaultBatchConcurrency}
 	for _, opt := range opts {
 		opt(queueOpt)
 	}
+	if err := validCompression(queueOpt.compression); err != nil {
+		return nil, err
+	}
+	if queueOpt.batchConcurrency <= 0 {
+		return nil, fmt.Errorf("batch concurrency should be positive")
+	}
+	dCodec, aCodec, err := mediaTypeCodecs(queueOpt.mediaTypes)
+	if err != nil {
+		return nil, err
+	}
 	baseUrl := endpoint + path.Join("/", queueOpt.basePath, queueName)
 	u, err := url.Parse(baseUrl)
 	if err != nil {
@@ -123,14 +197,26 @@ func NewQueueClient(endpoint, queueName, token string, opts ...QueueOption) (*Qu
 	if len(queueOpt.gid) == 0 {
 		queueOpt.gid = DefaultGroupName
 	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = queueOpt.batchConcurrency
 	cli := &QueueClient{
 		baseUrl:        u,
-		httpClient:     &http.Client{},
+		httpClient:     &http.Client{Transport: transport},
 		user:           NewQueueUser(queueOpt.uid, queueOpt.gid, token),
 		WebsocketWatch: true, // Watch through websocket by default
 		extraHeader:    queueOpt.extraHeaders,
-		DCodec:         types.DataFrameCodecFor(types.ContentTypeProtobuf),
-		ACodec:         types.AttributesCodecFor(types.ContentTypeProtobuf),
+		DCodec:         dCodec,
+		ACodec:         aCodec,
+
+		compression:          queueOpt.compression,
+		compressionThreshold: queueOpt.compressionThreshold,
+		batchConcurrency:     queueOpt.batchConcurrency,
+		chunking:             queueOpt.chunking,
+		blobStore:            queueOpt.blobStore,
+		blobThreshold:        queueOpt.blobThreshold,
+		onBlobError:          queueOpt.onBlobError,
+		keyProvider:          queueOpt.keyProvider,
+		allowPlaintext:       queueOpt.allowPlaintext,
 	}
 
 	return cli, nil
@@ -144,30 +230,47 @@ func readMessage(reader io.Reader) string {
 	return string(b)
 }
 
+// attrCall is the fetch of attributes in flight.
+type attrCall struct {
+	done chan struct{}
+	attr types.Attributes
+	err  error
+}
+
+// getAttr returns the attributes of queue, which are fetched once obtained successfully unless force.
 func (q *QueueClient) getAttr(force bool) (types.Attributes, error) {
-	var err error
-	defer func() {
-		if err != nil && len(q.attr) == 0 {
-			q.reset()
-		}
-	}()
-	if len(q.attr) == 0 {
-		q.once.Do(func() { err = q.obtainAttr() })
-	} else if force {
-		if err = q.obtainAttr(); err != nil {
-			return q.attr, fmt.Errorf("failed to obtain attributes, error: %v", err)
+	q.attrMu.Lock()
+	if len(q.attr) > 0 && !force {
+		attr := q.attr
+		q.attrMu.Unlock()
+		return attr, nil
+	}
+	call := q.attrCall
+	if call == nil {
+		call = &attrCall{done: make(chan struct{})}
+		q.attrCall = call
+		codec := q.ACodec
+		q.attrMu.Unlock()
+		// the request is sent without holding attrMu, then the result is swapped in under it
+		attr, err := q.obtainAttr(codec)
+		q.attrMu.Lock()
+		if err == nil {
+			q.attr = attr
 		}
+		call.attr, call.err = q.attr, err
+		q.attrCall = nil
+		close(call.done)
 	}
-
-	return q.attr, err
-}
-
-func (q *QueueClient) reset() {
-	q.attr = nil
-	q.once = sync.Once{}
+	q.attrMu.Unlock()
+	<-call.done
+	if call.err != nil && len(call.attr) > 0 {
+		return call.attr, fmt.Errorf("failed to obtain attributes, error: %v", call.err)
+	}
+	return call.attr, call.err
 }
 
-func (q *QueueClient) obtainAttr() error {
+// obtainAttr fetches the attributes of queue decoded by codec.
+func (q *QueueClient) obtainAttr(codec types.AttributesCodec) (types.Attributes, error) {
 	// make a copy of base url.
 	u := *q.baseUrl
 	qe := u.Query()
@@ -175,28 +278,27 @@ func (q *QueueClient) obtainAttr() error {
 	u.RawQuery = qe.Encode()
 	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	q.withAuthorization(req)
-	req.Header.Set("accept", q.ACodec.MediaType())
+	req.Header.Set("accept", codec.MediaType())
 	resp, err := q.httpClient.Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != 200 {
-		return fmt.Errorf("visiting: %s, unexpected status code: %d, body: %s", u.String(), resp.StatusCode, string(body))
+		return nil, fmt.Errorf("visiting: %s, unexpected status code: %d, body: %s", u.String(), resp.StatusCode, string(body))
 	}
 	attr := types.Attributes{}
-	if err = q.ACodec.Decode(body, &attr); err != nil {
-		return err
+	if err = codec.Decode(body, &attr); err != nil {
+		return nil, err
 	}
-	q.attr = attr
-	return nil
+	return attr, nil
 }
 
 // withIdentity populates user and group id into request.
@@ -308,7 +410,54 @@ func (q *QueueClient) Put(ctx context.Context, data []byte, tags types.Tags) (ui
 
 // PutWithPriority puts data into queue with priority. It returns the index of the data in queue, and generated request id.
 // The prioritized data will be received by Watcher before normal data.
+// Data exceeding the meta.maxPayloadBytes attribute of queue fails with *PayloadTooLargeError, unless chunking is enabled.
+// The limit applies to the bytes sent, i.e. after compression.
 func (q *QueueClient) PutWithPriority(ctx context.Context, data []byte, tags types.Tags, prio types.Priority) (index uint64, requestId string, err error) {
+	if q.keyProvider != nil {
+		if err = tags.Validate(); err != nil {
+			return 0, "", err
+		}
+		if data, tags, err = q.encrypt(ctx, data, tags); err != nil {
+			return 0, "", err
+		}
+	}
+	if q.blobStore != nil && len(data) > q.blobThreshold {
+		if err = tags.Validate(); err != nil {
+			return 0, "", err
+		}
+		if tags, err = q.offload(ctx, data, tags); err != nil {
+			return 0, "", err
+		}
+		data = nil
+	}
+	limit, err := q.maxPayloadBytes()
+	if err != nil {
+		return 0, "", err
+	}
+	body, encoding, err := q.compressPayload(data)
+	if err != nil {
+		return 0, "", err
+	}
+	if limit > 0 && len(body) > limit {
+		if !q.chunking {
+			return 0, "", &PayloadTooLargeError{Size: len(body), Limit: limit}
+		}
+		return q.putChunks(ctx, data, tags, prio, limit)
+	}
+	return q.put(ctx, body, encoding, tags, prio)
+}
+
+// compressPayload compresses data put into queue, encrypted payloads are sent as is since they are
+// compressed before encryption, the ciphertext does not compress.
+func (q *QueueClient) compressPayload(data []byte) ([]byte, string, error) {
+	if q.keyProvider != nil {
+		return data, "", nil
+	}
+	return compress(q.compression, q.compressionThreshold, data)
+}
+
+// put sends the data encoded with the content encoding into queue.
+func (q *QueueClient) put(ctx context.Context, data []byte, encoding string, tags types.Tags, prio types.Priority) (index uint64, requestId string, err error) {
 	// make a copy of base url.
 	u := *q.baseUrl
 	qe := u.Query()
@@ -320,6 +469,9 @@ func (q *QueueClient) PutWithPriority(ctx context.Context, data []byte, tags typ
 	if err != nil {
 		return 0, requestId, err
 	}
+	if len(encoding) != 0 {
+		req.Header.Set(headerContentEncoding, encoding)
+	}
 	if err := q.withIdentity(req); err != nil {
 		return 0, requestId, err
 	}
@@ -348,6 +500,39 @@ func (q *QueueClient) PutWithPriority(ctx context.Context, data []byte, tags typ
 	return index, requestId, nil
 }
 
+// PutResult is the result of a frame put by PutBatch.
+type PutResult struct {
+	Index     uint64
+	RequestId string
+	Err       error
+}
+
+// PutBatch puts the data of frames into queue with their tags, the requests are pipelined over
+// concurrent connections. It returns the results in the order of frames, and the first error if any
+// frame failed.
+func (q *QueueClient) PutBatch(ctx context.Context, frames []types.DataFrame) ([]PutResult, error) {
+	results := make([]PutResult, len(frames))
+	tokens := make(chan struct{}, q.batchConcurrency)
+	var wg sync.WaitGroup
+	for i := range frames {
+		tokens <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-tokens }()
+			result := &results[i]
+			result.Index, result.RequestId, result.Err = q.Put(ctx, frames[i].Data, frames[i].Tags)
+		}(i)
+	}
+	wg.Wait()
+	for i := range results {
+		if results[i].Err != nil {
+			return results, fmt.Errorf("put frame %d of batch: %v", i, results[i].Err)
+		}
+	}
+	return results, nil
+}
+
 // GetByIndex gets data from queue by index,  make convenience wrapper for Get.
 func (q *QueueClient) GetByIndex(ctx context.Context, index uint64) (dfs []types.DataFrame, err error) {
 	return q.Get(ctx, index, 1, time.Duration(0), true, types.Tags{})
@@ -365,7 +550,77 @@ func (q *QueueClient) GetByRequestId(ctx context.Context, requestId string) (dfs
 //   - timeout: the timeout duration to wait for data, if timeout is 0, it will return immediately.
 //   - autoDelete: if autoDelete is true, the data will be deleted from queue after it is read.
 //   - tags: the tags to filter data.
+//
+// Frames failed to be received by client side features, e.g. their blobs are missing, are returned as
+// received from queue with the error in Message along with a *ReceiveError, so the frames deleted by
+// autoDelete are not lost.
 func (q *QueueClient) Get(ctx context.Context, index uint64, length int, timeout time.Duration, autoDelete bool, tags types.Tags) (dfs []types.DataFrame, err error) {
+	dfs, err = q.get(ctx, index, length, timeout, autoDelete, tags)
+	if err != nil || !q.transformsFrames() {
+		return dfs, err
+	}
+	received := make([]types.DataFrame, 0, len(dfs))
+	var failures []error
+	for _, df := range dfs {
+		transformed, keep, err := q.receive(ctx, df, autoDelete)
+		if err != nil {
+			failures = append(failures, err)
+			transformed = types.DataFrame{Index: df.Index, Tags: df.Tags, Data: df.Data, Message: err.Error()}
+		} else if !keep {
+			continue
+		}
+		received = append(received, transformed)
+	}
+	if len(failures) > 0 {
+		return received, &ReceiveError{Errors: failures}
+	}
+	return received, nil
+}
+
+// ReceiveError is returned by Get along with the frames when some of them failed to be received.
+type ReceiveError struct {
+	Errors []error
+}
+
+func (e *ReceiveError) Error() string {
+	return fmt.Sprintf("%d frames failed to be received, the first one: %v", len(e.Errors), e.Errors[0])
+}
+
+func (e *ReceiveError) Unwrap() error {
+	return e.Errors[0]
+}
+
+// transformsFrames tells whether frames received from queue are transformed by client side features.
+func (q *QueueClient) transformsFrames() bool {
+	return q.chunking || q.blobStore != nil || q.keyProvider != nil
+}
+
+// receive applies the client side features to the frame received from queue, keep is false for the
+// frames which are parts of others.
+func (q *QueueClient) receive(ctx context.Context, df types.DataFrame, autoDelete bool) (types.DataFrame, bool, error) {
+	if q.chunking {
+		var keep bool
+		var err error
+		if df, keep, err = q.assembleFrame(ctx, df, autoDelete); err != nil || !keep {
+			return df, keep, err
+		}
+	}
+	if q.blobStore != nil {
+		var err error
+		if df, err = q.claim(ctx, df, autoDelete); err != nil {
+			return df, true, err
+		}
+	}
+	if q.keyProvider != nil {
+		var err error
+		if df, err = q.decrypt(ctx, df); err != nil {
+			return df, true, err
+		}
+	}
+	return df, true, nil
+}
+
+func (q *QueueClient) get(ctx context.Context, index uint64, length int, timeout time.Duration, autoDelete bool, tags types.Tags) (dfs []types.DataFrame, err error) {
 	var ret []types.DataFrame
 	u := *q.baseUrl
 	eq := u.Query()
@@ -385,7 +640,11 @@ func (q *QueueClient) Get(ctx context.Context, index uint64, length int, timeout
 	if err != nil {
 		return ret, err
 	}
-	req.Header.Set("Accept", q.DCodec.MediaType())
+	codec := q.DCodec
+	req.Header.Set("Accept", codec.MediaType())
+	if q.compression != CompressionNone {
+		req.Header.Set(headerAcceptEncoding, acceptEncoding())
+	}
 	if err := q.withIdentity(req); err != nil {
 		return ret, err
 	}
@@ -400,11 +659,14 @@ func (q *QueueClient) Get(ctx context.Context, index uint64, length int, timeout
 		return ret, err
 	}
 	defer resp.Body.Close()
+	if data, err = decompress(resp.Header.Get(headerContentEncoding), data); err != nil {
+		return ret, err
+	}
 	if resp.StatusCode >= 300 || resp.StatusCode < 200 {
 		return ret, fmt.Errorf("visiting: %s, unexpected status code: %d, message: %s", u.String(), resp.StatusCode, string(data))
 	}
 
-	return q.DCodec.DecodeList(data)
+	return codec.DecodeList(data)
 }
 
 func boolString(b bool) string {
@@ -611,7 +873,8 @@ func (h *httpWatcher) run() {
 				// klog.Errorf("failed to decode, err: %v", err)
 				return
 			}
-			buf.Reset()
+			// decoded frames may refer to the buffer, e.g. by registered zero-copy codecs
+			buf = bytes.NewBuffer(nil)
 			h.ch <- df
 		} else {
 			break
@@ -619,6 +882,49 @@ func (h *httpWatcher) run() {
 	}
 }
 
+// receiveWatcher applies the client side features to the frames of watcher.
+type receiveWatcher struct {
+	watcher types.Watcher
+	ctx     context.Context
+	q       *QueueClient
+	ch      chan types.DataFrame
+	// autocommit is whether the frames are committed by queue once delivered.
+	autocommit bool
+}
+
+// transformWatcher wraps the watcher if frames are transformed by client side features.
+func (q *QueueClient) transformWatcher(ctx context.Context, watcher types.Watcher, autocommit bool) types.Watcher {
+	if !q.transformsFrames() {
+		return watcher
+	}
+	w := &receiveWatcher{watcher: watcher, ctx: ctx, q: q, ch: make(chan types.DataFrame, 100), autocommit: autocommit}
+	go w.run()
+	return w
+}
+
+func (w *receiveWatcher) FrameChan() <-chan types.DataFrame {
+	return w.ch
+}
+
+func (w *receiveWatcher) Close() {
+	w.watcher.Close()
+}
+
+func (w *receiveWatcher) run() {
+	defer close(w.ch)
+	for df := range w.watcher.FrameChan() {
+		received, keep, err := w.q.receive(w.ctx, df, w.autocommit)
+		if err != nil {
+			// delivered as received from queue with the error in Message, which consumers must check
+			// before the data, so the frame can still be acknowledged by its index
+			received = types.DataFrame{Index: df.Index, Tags: df.Tags, Data: df.Data, Message: err.Error()}
+		} else if !keep {
+			continue
+		}
+		w.ch <- received
+	}
+}
+
 func (q *QueueClient) Watch(ctx context.Context, index, window uint64, indexOnly bool, autocommit bool) (types.Watcher, error) {
 	return q.WatchByTag(ctx, index, window, indexOnly, autocommit, types.Tags{})
 }
@@ -665,17 +971,19 @@ func (q *QueueClient) WatchByTag(ctx context.Context, index, window uint64, inde
 		gidHeader := attr[types.GroupIdentifyHeader]
 		// set websocket request headers.
 		header.Set(uidHeader, q.user.Uid())
-		header.Set("Accept", q.DCodec.MediaType())
+		codec := q.DCodec
+		header.Set("Accept", codec.MediaType())
 		header.Set(HeaderAuthorization, q.user.Token())
 		if len(gidHeader) > 0 {
 			header.Set(gidHeader, q.user.Gid())
 		}
 		config.Header = header
-		watcher, err := newReconnectWatcher(ctx, cancel, config, q.DCodec)
+		watcher, err := newReconnectWatcher(ctx, cancel, config, codec)
 		if err != nil {
 			cancel()
+			return nil, err
 		}
-		return watcher, err
+		return q.transformWatcher(ctx, watcher, autocommit), nil
 
 	} else {
 		// default http watch.
@@ -684,7 +992,8 @@ func (q *QueueClient) WatchByTag(ctx context.Context, index, window uint64, inde
 			cancel()
 			return nil, err
 		}
-		req.Header.Set("Accept", q.DCodec.MediaType())
+		codec := q.DCodec
+		req.Header.Set("Accept", codec.MediaType())
 		if err := q.withIdentity(req); err != nil {
 			cancel()
 			return nil, err
@@ -701,12 +1010,13 @@ func (q *QueueClient) WatchByTag(ctx context.Context, index, window uint64, inde
 			return nil, fmt.Errorf("unexpected status code: %d, message: %s", resp.StatusCode, string(content))
 		}
 		reader := types.NewLengthDelimitedFrameReader(resp.Body)
-		return newHTTPWatcher(ctx, cancel, reader, q.DCodec), nil
+		return q.transformWatcher(ctx, newHTTPWatcher(ctx, cancel, reader, codec), autocommit), nil
 	}
 }
 
 // Commit commits the indexes to the queue, as the result, the data in queue will not be delivered again.
 func (q *QueueClient) Commit(ctx context.Context, indexes ...uint64) error {
+	indexes = q.chunks.expand(indexes)
 	// make a copy of base url.
 	u := *q.baseUrl
 	var indexStr []string
@@ -733,10 +1043,12 @@ func (q *QueueClient) Commit(ctx context.Context, indexes ...uint64) error {
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("visiting: %s, unexpected status code %d, message: %s", u.String(), resp.StatusCode, readMessage(resp.Body))
 	}
+	q.cleanupBlobs(ctx, indexes)
 	return nil
 }
 
 func (q *QueueClient) Negative(ctx context.Context, code types.Code, reason string, indexes ...uint64) error {
+	indexes = q.chunks.expand(indexes)
 	// make a copy of base url.
 	u := *q.baseUrl
 	var indexStr []string
@@ -766,11 +1078,13 @@ func (q *QueueClient) Negative(ctx context.Context, code types.Code, reason stri
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("visiting: %s, unexpected status code %d, message: %s", u.String(), resp.StatusCode, readMessage(resp.Body))
 	}
+	q.forgetBlobs(indexes)
 	return nil
 }
 
 // Del deletes the indexes from the queue, the content of the indexes will also be deleted.
 func (q *QueueClient) Del(ctx context.Context, indexes ...uint64) error {
+	indexes = q.chunks.expand(indexes)
 	// make a copy of base url.
 	u := *q.baseUrl
 	var indexStr []string
@@ -796,6 +1110,7 @@ func (q *QueueClient) Del(ctx context.Context, indexes ...uint64) error {
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("visiting: %s, unexpected status code %d, message: %s", u.String(), resp.StatusCode, readMessage(resp.Body))
 	}
+	q.cleanupBlobs(ctx, indexes)
 	return nil
 }
 
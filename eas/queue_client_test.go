@@ -1,10 +1,16 @@
 package eas
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"github.com/pai-eas/eas-golang-sdk/eas/internal/queuetest"
 	"github.com/pai-eas/eas-golang-sdk/eas/types"
+	"net/http"
+	"net/http/httptest"
 	"strconv"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -208,3 +214,145 @@ func TestWatchWithReconnect(t *testing.T) {
 	watcher.Close()
 	cancel()
 }
+
+func TestQueuePutBatch(t *testing.T) {
+	server := queuetest.NewServer()
+	defer server.Close()
+	server.Attributes[types.MaxPayloadBytes] = "3"
+	client := newTestQueueClient(t, server, WithBatchConcurrency(4))
+
+	var frames []types.DataFrame
+	for i := 0; i < 20; i++ {
+		frames = append(frames, types.DataFrame{Data: []byte(strconv.Itoa(i)), Tags: types.Tags{"i": strconv.Itoa(i)}})
+	}
+	results, err := client.PutBatch(context.Background(), frames)
+	assertNoError(t, err)
+	assertEqual(t, len(results), 20)
+	got := map[uint64]string{}
+	for _, frame := range server.Frames() {
+		got[frame.Index.Uint64()] = string(frame.Data)
+	}
+	for i, result := range results {
+		assertEqual(t, got[result.Index], strconv.Itoa(i))
+		assertEqual(t, result.RequestId, "req-"+strconv.FormatUint(result.Index, 10))
+	}
+
+	results, err = client.PutBatch(context.Background(), []types.DataFrame{{Data: []byte("a")}, {Data: []byte("abcd")}})
+	if err == nil || results[0].Err != nil || results[1].Err == nil {
+		t.Fatalf("unexpected results: %v, %v", results, err)
+	}
+}
+
+func TestQueuePayloadLimit(t *testing.T) {
+	server := queuetest.NewServer()
+	defer server.Close()
+	server.Attributes[types.MaxPayloadBytes] = "4"
+
+	_, _, err := newTestQueueClient(t, server).Put(context.Background(), []byte("0123456789"), nil)
+	var tooLarge *PayloadTooLargeError
+	if !errors.As(err, &tooLarge) || tooLarge.Size != 10 || tooLarge.Limit != 4 {
+		t.Fatalf("expect PayloadTooLargeError, got %v", err)
+	}
+	assertEqual(t, server.Puts(), 0)
+
+	client := newTestQueueClient(t, server, WithChunking())
+	index, _, err := client.Put(context.Background(), []byte("0123456789"), types.Tags{"k": "v"})
+	assertNoError(t, err)
+	_, _, err = client.Put(context.Background(), []byte("abc"), nil)
+	assertNoError(t, err)
+	assertEqual(t, server.Puts(), 4)
+
+	frames, err := client.Get(context.Background(), 0, 10, 0, false, types.Tags{})
+	assertNoError(t, err)
+	assertEqual(t, len(frames), 2)
+	assertEqual(t, frames[0].Index.Uint64(), index)
+	assertEqual(t, string(frames[0].Data), "0123456789")
+	assertEqual(t, frames[0].Tags.String(), "tags[k=v requestId=req-1]")
+	assertEqual(t, string(frames[1].Data), "abc")
+
+	watcher, err := client.Watch(context.Background(), 0, 10, false, false)
+	assertNoError(t, err)
+	defer watcher.Close()
+	frame := <-watcher.FrameChan()
+	assertEqual(t, string(frame.Data), "0123456789")
+	assertNoError(t, client.Commit(context.Background(), frame.Index.Uint64()))
+	frame = <-watcher.FrameChan()
+	assertEqual(t, string(frame.Data), "abc")
+	assertEqual(t, fmt.Sprint(queuetest.SortIndexes(server.Committed())), "[1 2 3]")
+
+	// the limit applies to the compressed payload sent
+	server = queuetest.NewServer()
+	defer server.Close()
+	server.Attributes[types.MaxPayloadBytes] = "100"
+	compressed := newTestQueueClient(t, server, WithCompression(CompressionGzip, 16))
+	_, _, err = compressed.Put(context.Background(), bytes.Repeat([]byte("a"), 1000), nil)
+	assertNoError(t, err)
+	assertEqual(t, server.Puts(), 1)
+}
+
+func TestQueueMediaType(t *testing.T) {
+	server := queuetest.NewServer()
+	defer server.Close()
+	client := newTestQueueClient(t, server, WithMediaType(types.ContentTypeJSON))
+	ctx := context.Background()
+
+	index, _, err := client.Put(ctx, []byte("json data"), types.Tags{"k": "v"})
+	assertNoError(t, err)
+	frames, err := client.Get(ctx, index, 1, 0, false, types.Tags{})
+	assertNoError(t, err)
+	assertEqual(t, string(frames[0].Data), "json data")
+	assertEqual(t, frames[0].Tags.String(), "tags[k=v requestId=req-1]")
+
+	_, _, err = client.Put(ctx, []byte("next"), nil)
+	assertNoError(t, err)
+	watcher, err := client.Watch(ctx, 0, 10, false, false)
+	assertNoError(t, err)
+	defer watcher.Close()
+	for _, data := range []string{"json data", "next"} {
+		frame := <-watcher.FrameChan()
+		assertEqual(t, string(frame.Data), data)
+	}
+
+	for _, mediaType := range []string{"application/unknown", types.ContentTypeFlatbuffer} {
+		if _, err = NewQueueClient(server.URL, "", "token", WithMediaType(mediaType)); !errors.Is(err, types.ErrUnsupportedMediaType) {
+			t.Fatalf("expect ErrUnsupportedMediaType of %s, got %v", mediaType, err)
+		}
+	}
+}
+
+func TestQueueAttributesFetchedUnlocked(t *testing.T) {
+	var fetches int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		<-release
+		codec, _ := types.AttributesCodecFor(types.ContentTypeProtobuf)
+		codec.Encode(types.Attributes{types.Name: "test"}, w)
+	}))
+	defer server.Close()
+	client, err := NewQueueClient(server.URL, "test", "", WithBasePath(""))
+	assertNoError(t, err)
+
+	errs := make(chan error, 2)
+	for i := 0; i < cap(errs); i++ {
+		go func() {
+			attr, err := client.getAttr(false)
+			if err == nil && attr[types.Name] != "test" {
+				err = fmt.Errorf("unexpected attributes %v", attr)
+			}
+			errs <- err
+		}()
+	}
+	// the lock is free while the attributes are fetched
+	for atomic.LoadInt32(&fetches) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	client.attrMu.Lock()
+	client.attrMu.Unlock()
+	close(release)
+	for i := 0; i < cap(errs); i++ {
+		assertNoError(t, <-errs)
+	}
+	// concurrent callers share the fetch
+	assertEqual(t, atomic.LoadInt32(&fetches), int32(1))
+}
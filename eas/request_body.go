@@ -0,0 +1,149 @@
+package eas
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"sync"
+)
+
+// requestBody is the payload of a request to the service. It can be replayed for retrying when
+// it's made of bytes or when the underlying reader is able to seek back to where it started.
+type requestBody struct {
+	data     []byte
+	reader   io.Reader
+	seeker   io.Seeker
+	offset   int64
+	opened   bool
+	length   int64
+	encoding string
+
+	contentMd5 string
+
+	// release recycles data once the body is finished and all the readers of data are closed, as
+	// transports may still read a request body after returning the response.
+	release func()
+	mu      sync.Mutex
+	readers int
+	done    bool
+}
+
+// dataReader is the reader of the data of body, which is closed by the transport once sent.
+type dataReader struct {
+	*bytes.Reader
+	body *requestBody
+	once sync.Once
+}
+
+func (r *dataReader) Close() error {
+	r.once.Do(r.body.closeReader)
+	return nil
+}
+
+func (b *requestBody) closeReader() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.readers--
+	b.tryRelease()
+}
+
+// finish tells the body will not be opened anymore, data is released once its readers are closed.
+func (b *requestBody) finish() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.done = true
+	b.tryRelease()
+}
+
+// tryRelease releases data if possible, b.mu must be held.
+func (b *requestBody) tryRelease() {
+	if b.done && b.readers == 0 && b.release != nil {
+		b.release()
+		b.release = nil
+	}
+}
+
+// newBytesBody returns a replayable body made of data encoded with the given content encoding.
+func newBytesBody(data []byte, encoding string) *requestBody {
+	return &requestBody{
+		data:       data,
+		length:     int64(len(data)),
+		encoding:   encoding,
+		contentMd5: md5sum(data),
+	}
+}
+
+// newStreamBody returns a body reading from reader. If the reader is seekable, the content md5 and
+// the length are computed by reading it through once and seeking back to the current offset.
+func newStreamBody(reader io.Reader) (*requestBody, error) {
+	body := &requestBody{reader: reader, length: -1}
+	if reader == nil {
+		body.length = 0
+		return body, nil
+	}
+	seeker, ok := reader.(io.Seeker)
+	if !ok {
+		return body, nil
+	}
+	offset, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		// not really seekable, e.g. os.Stdin
+		return body, nil
+	}
+	h := md5.New()
+	n, err := io.Copy(h, reader)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	body.seeker = seeker
+	body.offset = offset
+	body.length = n
+	body.contentMd5 = hex.EncodeToString(h.Sum(nil))
+	return body, nil
+}
+
+// replayable reports whether the body can be sent once again.
+func (b *requestBody) replayable() bool {
+	return b.reader == nil || b.seeker != nil
+}
+
+// getBody opens the body for http.Request.GetBody, which lets the transport resend bytes bodies.
+func (b *requestBody) getBody() (io.ReadCloser, error) {
+	r, err := b.open()
+	if err != nil {
+		return nil, err
+	}
+	if rc, ok := r.(io.ReadCloser); ok {
+		return rc, nil
+	}
+	return ioutil.NopCloser(r), nil
+}
+
+// open returns a reader from the start of the body.
+func (b *requestBody) open() (io.Reader, error) {
+	if b.reader == nil {
+		if b.data == nil {
+			return nil, nil
+		}
+		if b.release == nil {
+			return bytes.NewReader(b.data), nil
+		}
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.readers++
+		return &dataReader{Reader: bytes.NewReader(b.data), body: b}, nil
+	}
+	if b.opened && b.seeker != nil {
+		if _, err := b.seeker.Seek(b.offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+	b.opened = true
+	// hide the Close method from http client, which closes the body after sending
+	return struct{ io.Reader }{b.reader}, nil
+}
@@ -0,0 +1,378 @@
+package eas
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pai-eas/eas-golang-sdk/eas/types/tf_predict_protos"
+	"github.com/pai-eas/eas-golang-sdk/eas/types/torch_predict_protos"
+)
+
+// ErrOutputNotFound is wrapped by the errors of response accessors when the output name or index
+// is absent from the response, check it with errors.Is.
+var ErrOutputNotFound = errors.New("output not found")
+
+// DtypeMismatchError is returned by response accessors when the output is of a dtype other than
+// the one the accessor reads.
+type DtypeMismatchError struct {
+	Output   string
+	Expected []string
+	Actual   string
+}
+
+// Error for error interface
+func (err *DtypeMismatchError) Error() string {
+	return fmt.Sprintf("output [%s] is of dtype %s, expected %s", err.Output, err.Actual, strings.Join(err.Expected, " or "))
+}
+
+// OutputNames returns the sorted names of outputs in the response.
+func (tresp *TFResponse) OutputNames() []string {
+	names := make([]string, 0, len(tresp.Response.Outputs))
+	for name := range tresp.Response.Outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NumOutputs returns the number of outputs in the response.
+func (tresp *TFResponse) NumOutputs() int {
+	return len(tresp.Response.Outputs)
+}
+
+// Output returns the output named outputName, ok is false if it's absent.
+func (tresp *TFResponse) Output(outputName string) (output *tf_predict_protos.ArrayProto, ok bool) {
+	output, ok = tresp.Response.Outputs[outputName]
+	return output, ok && output != nil
+}
+
+// TensorShape returns the shape of output like GetTensorShape, or an error if the output is absent.
+func (tresp *TFResponse) TensorShape(outputName string) ([]int64, error) {
+	output, ok := tresp.Output(outputName)
+	if !ok {
+		return nil, fmt.Errorf("output [%s]: %w", outputName, ErrOutputNotFound)
+	}
+	return output.GetArrayShape().GetDim(), nil
+}
+
+// checkedOutput returns the output named outputName if it's of one of the dtypes.
+func (tresp *TFResponse) checkedOutput(outputName string, dtypes ...tf_predict_protos.ArrayDataType) (*tf_predict_protos.ArrayProto, error) {
+	output, ok := tresp.Output(outputName)
+	if !ok {
+		return nil, fmt.Errorf("output [%s]: %w", outputName, ErrOutputNotFound)
+	}
+	expected := make([]string, 0, len(dtypes))
+	for _, dtype := range dtypes {
+		if output.Dtype == dtype {
+			return output, nil
+		}
+		expected = append(expected, dtype.String())
+	}
+	return nil, &DtypeMismatchError{Output: outputName, Expected: expected, Actual: output.Dtype.String()}
+}
+
+// FloatVal returns the content of output like GetFloatVal, or an error if the output is absent or not of DT_FLOAT.
+// Number outputs of JSON responses are accepted as well, whose dtype is inferred.
+func (tresp *TFResponse) FloatVal(outputName string) ([]float32, error) {
+	if output, ok := tresp.Output(outputName); ok && tresp.jsonInferred(output) {
+		return jsonFloats(output), nil
+	}
+	output, err := tresp.checkedOutput(outputName, TfType_DT_FLOAT)
+	if err != nil {
+		return nil, err
+	}
+	return output.GetFloatVal(), nil
+}
+
+// DoubleVal returns the content of output like GetDoubleVal, or an error if the output is absent or not of DT_DOUBLE.
+// Number outputs of JSON responses are accepted as well, whose dtype is inferred.
+func (tresp *TFResponse) DoubleVal(outputName string) ([]float64, error) {
+	if output, ok := tresp.Output(outputName); ok && tresp.jsonInferred(output) {
+		return jsonDoubles(output), nil
+	}
+	output, err := tresp.checkedOutput(outputName, TfType_DT_DOUBLE)
+	if err != nil {
+		return nil, err
+	}
+	return output.GetDoubleVal(), nil
+}
+
+// IntVal returns the content of output like GetIntVal, or an error if the output is absent or not of DT_INT32 or DT_QINT32.
+func (tresp *TFResponse) IntVal(outputName string) ([]int32, error) {
+	output, err := tresp.checkedOutput(outputName, TfType_DT_INT32, TfType_DT_QINT32)
+	if err != nil {
+		return nil, err
+	}
+	return output.GetIntVal(), nil
+}
+
+// Int64Val returns the content of output like GetInt64Val, or an error if the output is absent or not of DT_INT64.
+func (tresp *TFResponse) Int64Val(outputName string) ([]int64, error) {
+	output, err := tresp.checkedOutput(outputName, TfType_DT_INT64)
+	if err != nil {
+		return nil, err
+	}
+	return output.GetInt64Val(), nil
+}
+
+// BoolVal returns the content of output like GetBoolVal, or an error if the output is absent or not of DT_BOOL.
+func (tresp *TFResponse) BoolVal(outputName string) ([]bool, error) {
+	output, err := tresp.checkedOutput(outputName, TfType_DT_BOOL)
+	if err != nil {
+		return nil, err
+	}
+	return output.GetBoolVal(), nil
+}
+
+// StringVal returns the content of output like GetStringVal, or an error if the output is absent or not of DT_STRING.
+func (tresp *TFResponse) StringVal(outputName string) ([][]byte, error) {
+	output, err := tresp.checkedOutput(outputName, TfType_DT_STRING)
+	if err != nil {
+		return nil, err
+	}
+	return output.GetStringVal(), nil
+}
+
+// Uint8Val returns the content of output like GetUint8Val, or an error if the output is absent or not of DT_UINT8 or DT_QUINT8.
+func (tresp *TFResponse) Uint8Val(outputName string) ([]uint8, error) {
+	output, err := tresp.checkedOutput(outputName, TfType_DT_UINT8, TfType_DT_QUINT8)
+	if err != nil {
+		return nil, err
+	}
+	return unpackUint8(output.GetIntVal()), nil
+}
+
+// Int8Val returns the content of output like GetInt8Val, or an error if the output is absent or not of DT_INT8 or DT_QINT8.
+func (tresp *TFResponse) Int8Val(outputName string) ([]int8, error) {
+	output, err := tresp.checkedOutput(outputName, TfType_DT_INT8, TfType_DT_QINT8)
+	if err != nil {
+		return nil, err
+	}
+	return unpackInt8(output.GetIntVal()), nil
+}
+
+// Int16Val returns the content of output like GetInt16Val, or an error if the output is absent or not of DT_INT16 or DT_QINT16.
+func (tresp *TFResponse) Int16Val(outputName string) ([]int16, error) {
+	output, err := tresp.checkedOutput(outputName, TfType_DT_INT16, TfType_DT_QINT16)
+	if err != nil {
+		return nil, err
+	}
+	return unpackInt16(output.GetIntVal()), nil
+}
+
+// Uint16Val returns the content of output like GetUint16Val, or an error if the output is absent or not of DT_UINT16 or DT_QUINT16.
+func (tresp *TFResponse) Uint16Val(outputName string) ([]uint16, error) {
+	output, err := tresp.checkedOutput(outputName, TfType_DT_UINT16, TfType_DT_QUINT16)
+	if err != nil {
+		return nil, err
+	}
+	return unpackUint16(output.GetIntVal()), nil
+}
+
+// HalfVal returns the content of output like GetHalfVal, or an error if the output is absent or not of DT_HALF.
+func (tresp *TFResponse) HalfVal(outputName string) ([]float32, error) {
+	output, err := tresp.checkedOutput(outputName, TfType_DT_HALF)
+	if err != nil {
+		return nil, err
+	}
+	return unpackHalf(output.GetIntVal()), nil
+}
+
+// BFloat16Val returns the content of output like GetBFloat16Val, or an error if the output is absent or not of DT_BFLOAT16.
+func (tresp *TFResponse) BFloat16Val(outputName string) ([]float32, error) {
+	output, err := tresp.checkedOutput(outputName, TfType_DT_BFLOAT16)
+	if err != nil {
+		return nil, err
+	}
+	return unpackBFloat16(output.GetIntVal()), nil
+}
+
+// Complex64Val returns the content of output like GetComplex64Val, or an error if the output is absent or not of DT_COMPLEX64.
+func (tresp *TFResponse) Complex64Val(outputName string) ([]complex64, error) {
+	output, err := tresp.checkedOutput(outputName, TfType_DT_COMPLEX64)
+	if err != nil {
+		return nil, err
+	}
+	return unpackComplex64(output.GetFloatVal()), nil
+}
+
+// Complex128Val returns the content of output like GetComplex128Val, or an error if the output is absent or not of DT_COMPLEX128.
+func (tresp *TFResponse) Complex128Val(outputName string) ([]complex128, error) {
+	output, err := tresp.checkedOutput(outputName, TfType_DT_COMPLEX128)
+	if err != nil {
+		return nil, err
+	}
+	return unpackComplex128(output.GetDoubleVal()), nil
+}
+
+// NumOutputs returns the number of outputs in the response.
+func (resp *TorchResponse) NumOutputs() int {
+	return len(resp.Response.Outputs)
+}
+
+// Output returns the output at outIndex, ok is false if the index is out of range.
+func (resp *TorchResponse) Output(outIndex int) (output *torch_predict_protos.ArrayProto, ok bool) {
+	output = resp.output(outIndex)
+	return output, output != nil
+}
+
+// output returns the output at outIndex, or nil if the index is out of range.
+func (resp *TorchResponse) output(outIndex int) *torch_predict_protos.ArrayProto {
+	if outIndex < 0 || outIndex >= len(resp.Response.Outputs) {
+		return nil
+	}
+	return resp.Response.Outputs[outIndex]
+}
+
+// TensorShape returns the shape of output like GetTensorShape, or an error if the index is out of range.
+func (resp *TorchResponse) TensorShape(outIndex int) ([]int64, error) {
+	output, ok := resp.Output(outIndex)
+	if !ok {
+		return nil, fmt.Errorf("output [%d]: %w", outIndex, ErrOutputNotFound)
+	}
+	return output.GetArrayShape().GetDim(), nil
+}
+
+// checkedOutput returns the output at outIndex if it's of one of the dtypes.
+func (resp *TorchResponse) checkedOutput(outIndex int, dtypes ...torch_predict_protos.ArrayDataType) (*torch_predict_protos.ArrayProto, error) {
+	output, ok := resp.Output(outIndex)
+	if !ok {
+		return nil, fmt.Errorf("output [%d]: %w", outIndex, ErrOutputNotFound)
+	}
+	expected := make([]string, 0, len(dtypes))
+	for _, dtype := range dtypes {
+		if output.Dtype == dtype {
+			return output, nil
+		}
+		expected = append(expected, dtype.String())
+	}
+	return nil, &DtypeMismatchError{Output: fmt.Sprint(outIndex), Expected: expected, Actual: output.Dtype.String()}
+}
+
+// FloatVal returns the content of output like GetFloatVal, or an error if the index is out of range or the output is not of DT_FLOAT.
+func (resp *TorchResponse) FloatVal(outIndex int) ([]float32, error) {
+	output, err := resp.checkedOutput(outIndex, TorchType_DT_FLOAT)
+	if err != nil {
+		return nil, err
+	}
+	return output.GetFloatVal(), nil
+}
+
+// DoubleVal returns the content of output like GetDoubleVal, or an error if the index is out of range or the output is not of DT_DOUBLE.
+func (resp *TorchResponse) DoubleVal(outIndex int) ([]float64, error) {
+	output, err := resp.checkedOutput(outIndex, TorchType_DT_DOUBLE)
+	if err != nil {
+		return nil, err
+	}
+	return output.GetDoubleVal(), nil
+}
+
+// IntVal returns the content of output like GetIntVal, or an error if the index is out of range or the output is not of DT_INT32 or DT_QINT32.
+func (resp *TorchResponse) IntVal(outIndex int) ([]int32, error) {
+	output, err := resp.checkedOutput(outIndex, TorchType_DT_INT32, TorchType_DT_QINT32)
+	if err != nil {
+		return nil, err
+	}
+	return output.GetIntVal(), nil
+}
+
+// Int64Val returns the content of output like GetInt64Val, or an error if the index is out of range or the output is not of DT_INT64.
+func (resp *TorchResponse) Int64Val(outIndex int) ([]int64, error) {
+	output, err := resp.checkedOutput(outIndex, TorchType_DT_INT64)
+	if err != nil {
+		return nil, err
+	}
+	return output.GetInt64Val(), nil
+}
+
+// BoolVal returns the content of output like GetBoolVal, or an error if the index is out of range or the output is not of DT_BOOL.
+func (resp *TorchResponse) BoolVal(outIndex int) ([]bool, error) {
+	output, err := resp.checkedOutput(outIndex, TorchType_DT_BOOL)
+	if err != nil {
+		return nil, err
+	}
+	return unpackBool(output.GetIntVal()), nil
+}
+
+// StringVal returns the content of output like GetStringVal, or an error if the index is out of range or the output is not of DT_STRING.
+func (resp *TorchResponse) StringVal(outIndex int) ([][]byte, error) {
+	output, err := resp.checkedOutput(outIndex, TorchType_DT_STRING)
+	if err != nil {
+		return nil, err
+	}
+	return output.GetStringVal(), nil
+}
+
+// Uint8Val returns the content of output like GetUint8Val, or an error if the index is out of range or the output is not of DT_UINT8 or DT_QUINT8.
+func (resp *TorchResponse) Uint8Val(outIndex int) ([]uint8, error) {
+	output, err := resp.checkedOutput(outIndex, TorchType_DT_UINT8, TorchType_DT_QUINT8)
+	if err != nil {
+		return nil, err
+	}
+	return unpackUint8(output.GetIntVal()), nil
+}
+
+// Int8Val returns the content of output like GetInt8Val, or an error if the index is out of range or the output is not of DT_INT8 or DT_QINT8.
+func (resp *TorchResponse) Int8Val(outIndex int) ([]int8, error) {
+	output, err := resp.checkedOutput(outIndex, TorchType_DT_INT8, TorchType_DT_QINT8)
+	if err != nil {
+		return nil, err
+	}
+	return unpackInt8(output.GetIntVal()), nil
+}
+
+// Int16Val returns the content of output like GetInt16Val, or an error if the index is out of range or the output is not of DT_INT16 or DT_QINT16.
+func (resp *TorchResponse) Int16Val(outIndex int) ([]int16, error) {
+	output, err := resp.checkedOutput(outIndex, TorchType_DT_INT16, TorchType_DT_QINT16)
+	if err != nil {
+		return nil, err
+	}
+	return unpackInt16(output.GetIntVal()), nil
+}
+
+// Uint16Val returns the content of output like GetUint16Val, or an error if the index is out of range or the output is not of DT_UINT16 or DT_QUINT16.
+func (resp *TorchResponse) Uint16Val(outIndex int) ([]uint16, error) {
+	output, err := resp.checkedOutput(outIndex, TorchType_DT_UINT16, TorchType_DT_QUINT16)
+	if err != nil {
+		return nil, err
+	}
+	return unpackUint16(output.GetIntVal()), nil
+}
+
+// HalfVal returns the content of output like GetHalfVal, or an error if the index is out of range or the output is not of DT_HALF.
+func (resp *TorchResponse) HalfVal(outIndex int) ([]float32, error) {
+	output, err := resp.checkedOutput(outIndex, TorchType_DT_HALF)
+	if err != nil {
+		return nil, err
+	}
+	return unpackHalf(output.GetIntVal()), nil
+}
+
+// BFloat16Val returns the content of output like GetBFloat16Val, or an error if the index is out of range or the output is not of DT_BFLOAT16.
+func (resp *TorchResponse) BFloat16Val(outIndex int) ([]float32, error) {
+	output, err := resp.checkedOutput(outIndex, TorchType_DT_BFLOAT16)
+	if err != nil {
+		return nil, err
+	}
+	return unpackBFloat16(output.GetIntVal()), nil
+}
+
+// Complex64Val returns the content of output like GetComplex64Val, or an error if the index is out of range or the output is not of DT_COMPLEX64.
+func (resp *TorchResponse) Complex64Val(outIndex int) ([]complex64, error) {
+	output, err := resp.checkedOutput(outIndex, TorchType_DT_COMPLEX64)
+	if err != nil {
+		return nil, err
+	}
+	return unpackComplex64(output.GetFloatVal()), nil
+}
+
+// Complex128Val returns the content of output like GetComplex128Val, or an error if the index is out of range or the output is not of DT_COMPLEX128.
+func (resp *TorchResponse) Complex128Val(outIndex int) ([]complex128, error) {
+	output, err := resp.checkedOutput(outIndex, TorchType_DT_COMPLEX128)
+	if err != nil {
+		return nil, err
+	}
+	return unpackComplex128(output.GetDoubleVal()), nil
+}
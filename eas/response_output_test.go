@@ -0,0 +1,59 @@
+package eas
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/pai-eas/eas-golang-sdk/eas/types/tf_predict_protos"
+	"github.com/pai-eas/eas-golang-sdk/eas/types/torch_predict_protos"
+)
+
+func TestTFResponseAccessors(t *testing.T) {
+	resp := TFResponse{}
+	resp.Response.Outputs = map[string]*tf_predict_protos.ArrayProto{
+		"scores": {Dtype: TfType_DT_FLOAT, ArrayShape: &tf_predict_protos.ArrayShape{Dim: []int64{2}}, FloatVal: []float32{0.1, 0.9}},
+		"labels": {Dtype: TfType_DT_STRING, StringVal: [][]byte{[]byte("cat")}},
+	}
+	if !reflect.DeepEqual(resp.OutputNames(), []string{"labels", "scores"}) {
+		t.Fatalf("unexpected output names: %v", resp.OutputNames())
+	}
+	assertEqual(t, resp.NumOutputs(), 2)
+
+	scores, err := resp.FloatVal("scores")
+	assertNoError(t, err)
+	assertEqual(t, scores[1], float32(0.9))
+
+	if _, err = resp.TensorShape("missing"); !errors.Is(err, ErrOutputNotFound) {
+		t.Fatalf("expect ErrOutputNotFound, got %v", err)
+	}
+	if shape := resp.GetTensorShape("missing"); shape != nil {
+		t.Fatalf("unexpected shape of missing output: %v", shape)
+	}
+	_, err = resp.Int64Val("scores")
+	if mismatch, ok := err.(*DtypeMismatchError); !ok || mismatch.Actual != "DT_FLOAT" {
+		t.Fatalf("expect DtypeMismatchError, got %v", err)
+	}
+}
+
+func TestTorchResponseAccessors(t *testing.T) {
+	resp := TorchResponse{}
+	resp.Response.Outputs = []*torch_predict_protos.ArrayProto{
+		{Dtype: TorchType_DT_INT64, ArrayShape: &torch_predict_protos.ArrayShape{Dim: []int64{1}}, Int64Val: []int64{7}},
+	}
+	assertEqual(t, resp.NumOutputs(), 1)
+	ids, err := resp.Int64Val(0)
+	assertNoError(t, err)
+	assertEqual(t, ids[0], int64(7))
+
+	if _, err = resp.FloatVal(1); !errors.Is(err, ErrOutputNotFound) {
+		t.Fatalf("expect ErrOutputNotFound, got %v", err)
+	}
+	if _, err = resp.FloatVal(0); err == nil {
+		t.Fatal("dtype mismatch should be reported")
+	}
+	// the legacy getters must not panic on out of range index
+	if len(resp.GetFloatVal(3)) != 0 || resp.GetTensorShape(-1) != nil {
+		t.Fatal("out of range output should be empty")
+	}
+}
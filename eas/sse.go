@@ -0,0 +1,156 @@
+package eas
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Event is a message of Server-Sent Events stream returned by the service.
+type Event struct {
+	// ID is the last event id seen in the stream when the event is dispatched.
+	ID string
+	// Event is the event type, empty for the default "message" events.
+	Event string
+	// Data is the payload of event, lines of multiple data fields are joined with "\n".
+	Data string
+	// Retry is the reconnection time in milliseconds suggested by the service, 0 if not set.
+	Retry int
+}
+
+// EventStream iterates over the events of a text/event-stream response.
+type EventStream struct {
+	body    io.ReadCloser
+	reader  *bufio.Reader
+	lastID  string
+	pending *Event
+	err     error
+}
+
+func newEventStream(body io.ReadCloser) *EventStream {
+	return &EventStream{
+		body:   body,
+		reader: bufio.NewReader(body),
+	}
+}
+
+// Recv returns the next event of the stream, it returns io.EOF when the stream ends normally.
+// Cancelling the context of the request interrupts a blocking Recv.
+func (s *EventStream) Recv() (*Event, error) {
+	if s.pending != nil {
+		event := s.pending
+		s.pending = nil
+		return event, nil
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	event, err := s.next()
+	if err != nil {
+		s.err = err
+		return nil, err
+	}
+	return event, nil
+}
+
+// Close closes the underlying response body, it's safe to call Close concurrently with Recv.
+func (s *EventStream) Close() error {
+	return s.body.Close()
+}
+
+// next parses the stream until an event is dispatched, following the rules of
+// https://html.spec.whatwg.org/multipage/server-sent-events.html#event-stream-interpretation
+func (s *EventStream) next() (*Event, error) {
+	var data strings.Builder
+	event := &Event{}
+	hasData := false
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			// an incomplete event at the end of stream is discarded
+			return nil, err
+		}
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+
+		if len(line) == 0 {
+			// a blank line dispatches the event
+			if !hasData {
+				event = &Event{}
+				continue
+			}
+			event.ID = s.lastID
+			event.Data = strings.TrimSuffix(data.String(), "\n")
+			return event, nil
+		}
+		if line[0] == ':' {
+			// comment line, usually used as keep-alive
+			continue
+		}
+
+		field, value := line, ""
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+		switch field {
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+			hasData = true
+		case "event":
+			event.Event = value
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				s.lastID = value
+			}
+		case "retry":
+			if retry, err := strconv.Atoi(value); err == nil && retry >= 0 {
+				event.Retry = retry
+			}
+		}
+	}
+}
+
+// PredictStream sends the request data and returns the events streamed by the service in
+// text/event-stream format, such as the tokens generated by LLM services. Endpoint discovery,
+// signing and compression are shared with BytesPredict. The request is retried on failures
+// only until the first event is received, it is never retried once an event has been delivered.
+// The caller must close the returned stream.
+func (p *PredictClient) PredictStream(ctx context.Context, requestData []byte, opts ...PredictOption) (*EventStream, error) {
+	requestData, encoding, err := compress(p.compression, p.compressionThreshold, requestData)
+	if err != nil {
+		return nil, NewPredictError(ErrorCodeCompressRequest, "", err.Error())
+	}
+	var stream *EventStream
+	call := newPredictCall(newBytesBody(requestData, encoding), append([]PredictOption{WithAccept("text/event-stream")}, opts...)...)
+	err = p.perform(ctx, p.streamClient(), call, func(resp *http.Response, url string) (bool, error) {
+		if resp.StatusCode != 200 {
+			message := readMessage(resp.Body)
+			resp.Body.Close()
+			return true, NewPredictError(resp.StatusCode, url, message)
+		}
+		body, err := decompressReader(resp.Header.Get(headerContentEncoding), resp.Body)
+		if err != nil {
+			resp.Body.Close()
+			return true, NewPredictError(ErrorCodeReadResponse, url, err.Error())
+		}
+		s := newEventStream(body)
+		// wait for the first event, the request can still be retried before it is delivered
+		event, err := s.next()
+		if err == io.EOF {
+			s.err = err
+		} else if err != nil {
+			s.Close()
+			return true, NewPredictError(ErrorCodeReadResponse, url, err.Error())
+		}
+		s.pending = event
+		stream = s
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
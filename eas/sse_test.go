@@ -0,0 +1,74 @@
+package eas
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestEventStreamParse(t *testing.T) {
+	input := ": keep-alive\n" +
+		"data: first\n\n" +
+		"event: delta\r\nid: 2\r\ndata: multi\r\ndata:line\r\n\r\n" +
+		"retry: 3000\ndata\n\n" +
+		"\n\n" +
+		"data: incomplete"
+	stream := newEventStream(ioutil.NopCloser(strings.NewReader(input)))
+	expects := []Event{
+		{Data: "first"},
+		{ID: "2", Event: "delta", Data: "multi\nline"},
+		{ID: "2", Retry: 3000, Data: ""},
+	}
+	for _, expect := range expects {
+		event, err := stream.Recv()
+		assertNoError(t, err)
+		if *event != expect {
+			t.Fatalf("got event %+v, expect %+v", *event, expect)
+		}
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Fatalf("expect EOF, got %v", err)
+	}
+}
+
+func TestPredictStream(t *testing.T) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&count, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		if r.Header.Get("Accept") != "text/event-stream" {
+			t.Errorf("unexpected accept header: %s", r.Header.Get("Accept"))
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		for _, token := range []string{"hello", "world"} {
+			w.Write([]byte("data: " + token + "\n\n"))
+			flusher.Flush()
+		}
+	}))
+	defer server.Close()
+
+	client := NewPredictClient(server.Listener.Addr().String(), "llm")
+	client.Init()
+	stream, err := client.PredictStream(context.Background(), []byte(`{"prompt": "hi"}`))
+	assertNoError(t, err)
+	defer stream.Close()
+	var tokens []string
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		assertNoError(t, err)
+		tokens = append(tokens, event.Data)
+	}
+	assertEqual(t, strings.Join(tokens, " "), "hello world")
+	assertEqual(t, atomic.LoadInt32(&count), int32(2))
+}
@@ -0,0 +1,309 @@
+package eas
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TabularProbabilityPrefix is the prefix of fields holding the probability of each label in the results
+// of PMML processor, e.g. "p_1" for label "1".
+const TabularProbabilityPrefix = "p_"
+
+// TabularRequest batches feature rows of tabular models, e.g. PMML or scorecard models, into the JSON
+// array [{"fea1": 1, "fea2": 2}, ...] expected by their processors.
+type TabularRequest struct {
+	rows []interface{}
+}
+
+// AddRow adds a row of features, which is a map[string]interface{} or a struct (or pointer to struct)
+// whose exported fields are named by json tags like encoding/json does.
+func (tr *TabularRequest) AddRow(row interface{}) error {
+	v := reflect.ValueOf(row)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	switch {
+	case v.Kind() == reflect.Struct:
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+	default:
+		return fmt.Errorf("row should be a struct or a map keyed by string, got %T", row)
+	}
+	tr.rows = append(tr.rows, row)
+	return nil
+}
+
+// Len returns the number of rows.
+func (tr *TabularRequest) Len() int {
+	return len(tr.rows)
+}
+
+// TabularResult is the result of a row returned by tabular models.
+type TabularResult struct {
+	// Fields holds all fields of the result as they are decoded from JSON
+	Fields map[string]interface{}
+	// Probabilities maps labels to their probabilities, taken from fields prefixed by "p_"
+	Probabilities map[string]float64
+}
+
+// Float returns the numeric field of result, e.g. the score of scorecard models, ok is false if the
+// field is absent or not a number.
+func (r *TabularResult) Float(name string) (value float64, ok bool) {
+	switch v := r.Fields[name].(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case float64:
+		return v, true
+	case string:
+		// some processors return numbers as strings
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// Label returns the label of highest probability, or an empty string if there is no probability.
+func (r *TabularResult) Label() string {
+	label, best := "", -1.0
+	for l, p := range r.Probabilities {
+		if p > best || (p == best && l < label) {
+			label, best = l, p
+		}
+	}
+	return label
+}
+
+// Decode decodes the fields of result into v, e.g. a struct with json tags.
+func (r *TabularResult) Decode(v interface{}) error {
+	data, err := json.Marshal(r.Fields)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func newTabularResult(fields map[string]interface{}) TabularResult {
+	result := TabularResult{Fields: fields, Probabilities: map[string]float64{}}
+	for name := range fields {
+		if !strings.HasPrefix(name, TabularProbabilityPrefix) {
+			continue
+		}
+		if p, ok := result.Float(name); ok {
+			result.Probabilities[strings.TrimPrefix(name, TabularProbabilityPrefix)] = p
+		}
+	}
+	return result
+}
+
+// TabularPredict sends the rows of request as a JSON array, and returns the result of each row in order.
+func (p *PredictClient) TabularPredict(ctx context.Context, request *TabularRequest, opts ...PredictOption) ([]TabularResult, error) {
+	var raw []json.RawMessage
+	if err := p.JSONPredict(ctx, request.rows, &raw, opts...); err != nil {
+		return nil, err
+	}
+	if len(raw) != len(request.rows) {
+		return nil, NewPredictError(ErrorCodeReadResponse, "",
+			fmt.Sprintf("%d results returned for %d rows", len(raw), len(request.rows)))
+	}
+	results := make([]TabularResult, len(raw))
+	for i, item := range raw {
+		// numbers are kept as json.Number to be precise
+		decoder := json.NewDecoder(strings.NewReader(string(item)))
+		decoder.UseNumber()
+		fields := map[string]interface{}{}
+		if err := decoder.Decode(&fields); err != nil {
+			return nil, NewPredictError(ErrorCodeReadResponse, "", fmt.Sprintf("result of row %d: %v", i, err))
+		}
+		results[i] = newTabularResult(fields)
+	}
+	return results, nil
+}
+
+type csvOptions struct {
+	batchSize   int
+	concurrency int
+	columns     []string
+}
+
+// CSVOption customizes ScoreCSV.
+type CSVOption func(*csvOptions)
+
+// WithCSVBatchSize sets the number of rows sent in a request, 100 by default.
+func WithCSVBatchSize(batchSize int) CSVOption {
+	return func(o *csvOptions) {
+		o.batchSize = batchSize
+	}
+}
+
+// WithCSVConcurrency sets the number of requests in flight, 1 by default. Rows are written in order anyway.
+func WithCSVConcurrency(concurrency int) CSVOption {
+	return func(o *csvOptions) {
+		o.concurrency = concurrency
+	}
+}
+
+// WithCSVColumns sets the result fields written after the input columns, by default they are all fields
+// of the first result in name order.
+func WithCSVColumns(columns ...string) CSVOption {
+	return func(o *csvOptions) {
+		o.columns = columns
+	}
+}
+
+type csvBatch struct {
+	records [][]string
+	results []TabularResult
+	err     error
+	done    chan struct{}
+}
+
+// ScoreCSV scores the rows of CSV read from r in batches by TabularPredict, and writes them into w with
+// the result fields appended. The first row of input is the header naming the features, values which
+// parse as numbers are sent as numbers, the others as strings, and empty values are omitted.
+func (p *PredictClient) ScoreCSV(ctx context.Context, r io.Reader, w io.Writer, opts ...CSVOption) error {
+	o := &csvOptions{batchSize: 100, concurrency: 1}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.batchSize <= 0 || o.concurrency <= 0 {
+		return fmt.Errorf("batch size and concurrency should be positive")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("read csv header: %v", err)
+	}
+
+	// batches are scored concurrently and handed over to the writer in order
+	batches := make(chan *csvBatch, o.concurrency)
+	tokens := make(chan struct{}, o.concurrency)
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(batches)
+		for ctx.Err() == nil {
+			batch := &csvBatch{done: make(chan struct{})}
+			for len(batch.records) < o.batchSize {
+				record, err := reader.Read()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					readErr <- fmt.Errorf("read csv: %v", err)
+					return
+				}
+				batch.records = append(batch.records, record)
+			}
+			if len(batch.records) == 0 {
+				break
+			}
+			tokens <- struct{}{}
+			go func() {
+				defer func() { <-tokens }()
+				p.scoreCSVBatch(ctx, header, batch)
+			}()
+			batches <- batch
+		}
+		readErr <- ctx.Err()
+	}()
+
+	writer := csv.NewWriter(w)
+	columns := o.columns
+	headerWritten := false
+	for batch := range batches {
+		<-batch.done
+		err = batch.err
+		if err == nil && !headerWritten {
+			if columns == nil {
+				columns = resultColumns(batch.results[0])
+			}
+			err = writer.Write(append(append([]string{}, header...), columns...))
+			headerWritten = true
+		}
+		if err == nil {
+			err = writeCSVBatch(writer, columns, batch)
+		}
+		if err != nil {
+			// stop reading and wait for the batches in flight
+			cancel()
+			for batch := range batches {
+				<-batch.done
+			}
+			return err
+		}
+	}
+	if err = <-readErr; err != nil {
+		return err
+	}
+	if !headerWritten {
+		// no rows at all, the header is still written
+		if err = writer.Write(header); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func (p *PredictClient) scoreCSVBatch(ctx context.Context, header []string, batch *csvBatch) {
+	defer close(batch.done)
+	request := &TabularRequest{}
+	for _, record := range batch.records {
+		row := make(map[string]interface{}, len(header))
+		for i, name := range header {
+			if i >= len(record) || record[i] == "" {
+				continue
+			}
+			// numbers are sent as they are written, e.g. large integers keep their precision
+			if isJSONNumber(record[i]) {
+				row[name] = json.Number(record[i])
+			} else {
+				row[name] = record[i]
+			}
+		}
+		request.AddRow(row)
+	}
+	batch.results, batch.err = p.TabularPredict(ctx, request)
+}
+
+// isJSONNumber reports whether s is a JSON number literal, cells like "00123", "NaN" or "0x1p-2" are not
+// and sent as strings, so they reach the model unchanged.
+func isJSONNumber(s string) bool {
+	if len(s) == 0 || s[0] != '-' && (s[0] < '0' || s[0] > '9') || s[len(s)-1] < '0' || s[len(s)-1] > '9' {
+		return false
+	}
+	return json.Valid([]byte(s))
+}
+
+func resultColumns(result TabularResult) []string {
+	columns := make([]string, 0, len(result.Fields))
+	for name := range result.Fields {
+		columns = append(columns, name)
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+func writeCSVBatch(writer *csv.Writer, columns []string, batch *csvBatch) error {
+	for i, record := range batch.records {
+		out := append(append([]string{}, record...), make([]string, len(columns))...)
+		for j, column := range columns {
+			if value, ok := batch.results[i].Fields[column]; ok && value != nil {
+				out[len(record)+j] = fmt.Sprint(value)
+			}
+		}
+		if err := writer.Write(out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
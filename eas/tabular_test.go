@@ -0,0 +1,112 @@
+package eas
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newScorecardServer returns a server scoring each row by fea1 + fea2, with probabilities of label 1 and 0.
+func newScorecardServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rows := []map[string]interface{}{}
+		if err := json.NewDecoder(r.Body).Decode(&rows); err != nil {
+			t.Errorf("invalid request: %v", err)
+		}
+		results := make([]string, 0, len(rows))
+		for _, row := range rows {
+			fea1, _ := row["fea1"].(float64)
+			fea2, _ := row["fea2"].(float64)
+			p := (fea1 + fea2) / 10
+			results = append(results, fmt.Sprintf(`{"score": %v, "p_1": %v, "p_0": %v}`, fea1+fea2, p, 1-p))
+		}
+		w.Write([]byte("[" + strings.Join(results, ",") + "]"))
+	}))
+}
+
+func TestTabularPredict(t *testing.T) {
+	server := newScorecardServer(t)
+	defer server.Close()
+	client := NewPredictClient(server.Listener.Addr().String(), "scorecard_pmml_example")
+	client.Init()
+
+	type features struct {
+		Fea1 float64 `json:"fea1"`
+		Fea2 float64 `json:"fea2"`
+	}
+	req := TabularRequest{}
+	assertNoError(t, req.AddRow(features{Fea1: 1, Fea2: 2}))
+	assertNoError(t, req.AddRow(map[string]interface{}{"fea1": 4, "fea2": 4}))
+	if err := req.AddRow([]float64{1, 2}); err == nil {
+		t.Fatal("row of slice should be rejected")
+	}
+	results, err := client.TabularPredict(context.Background(), &req)
+	assertNoError(t, err)
+	assertEqual(t, len(results), 2)
+	score, ok := results[0].Float("score")
+	assertEqual(t, ok, true)
+	assertEqual(t, score, 3.0)
+	assertEqual(t, results[0].Label(), "0")
+	assertEqual(t, results[1].Label(), "1")
+	assertEqual(t, results[1].Probabilities["1"], 0.8)
+
+	decoded := struct {
+		Score float64 `json:"score"`
+	}{}
+	assertNoError(t, results[1].Decode(&decoded))
+	assertEqual(t, decoded.Score, 8.0)
+}
+
+func TestScoreCSV(t *testing.T) {
+	server := newScorecardServer(t)
+	defer server.Close()
+	client := NewPredictClient(server.Listener.Addr().String(), "scorecard_pmml_example")
+	client.Init()
+
+	input := "id,fea1,fea2\n"
+	for i := 0; i < 7; i++ {
+		input += fmt.Sprintf("row%d,%d,1\n", i, i)
+	}
+	out := &bytes.Buffer{}
+	err := client.ScoreCSV(context.Background(), strings.NewReader(input), out,
+		WithCSVBatchSize(2), WithCSVConcurrency(3), WithCSVColumns("score"))
+	assertNoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assertEqual(t, len(lines), 8)
+	assertEqual(t, lines[0], "id,fea1,fea2,score")
+	assertEqual(t, lines[7], "row6,6,1,7")
+}
+
+func TestScoreCSVLiterals(t *testing.T) {
+	var rows []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		decoder := json.NewDecoder(r.Body)
+		decoder.UseNumber()
+		if err := decoder.Decode(&rows); err != nil {
+			t.Errorf("invalid request: %v", err)
+		}
+		w.Write([]byte("[" + strings.Repeat(`{"score": 1},`, len(rows)-1) + `{"score": 1}]`))
+	}))
+	defer server.Close()
+	client := NewPredictClient(server.Listener.Addr().String(), "scorecard_pmml_example")
+	client.Init()
+
+	input := "id,account,fea1\n00123,12345678901234567891,NaN\n0042,-9007199254740993,-Inf\n7,1.50,1e3\n"
+	err := client.ScoreCSV(context.Background(), strings.NewReader(input), &bytes.Buffer{}, WithCSVColumns("score"))
+	assertNoError(t, err)
+	// leading zeros, large integers and non-finite values reach the model as they are written
+	expected := []map[string]interface{}{
+		{"id": "00123", "account": json.Number("12345678901234567891"), "fea1": "NaN"},
+		{"id": "0042", "account": json.Number("-9007199254740993"), "fea1": "-Inf"},
+		{"id": json.Number("7"), "account": json.Number("1.50"), "fea1": json.Number("1e3")},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Fatalf("expect rows %v, got %v", expected, rows)
+	}
+}
@@ -0,0 +1,406 @@
+package eas
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/pai-eas/eas-golang-sdk/eas/types/tf_predict_protos"
+	"github.com/pai-eas/eas-golang-sdk/eas/types/torch_predict_protos"
+)
+
+// Tensor is a multi-dimensional array with a dtype, a shape and a flat backing buffer in row-major order.
+// The dtype is one of the TfType_DT_* constants, which share values with TorchType_DT_*. The buffer is
+// a slice of the Go type of the dtype, half and bfloat16 are held as []float32, quantized types as the
+// slice of their underlying integers, and strings as [][]byte.
+type Tensor struct {
+	dtype tf_predict_protos.ArrayDataType
+	shape []int64
+	data  reflect.Value
+}
+
+// NewTensor returns a tensor of the dtype and shape backed by data, which must be a slice of the Go type
+// of the dtype with as many elements as the shape holds. The data is not copied.
+func NewTensor(dtype tf_predict_protos.ArrayDataType, shape []int64, data interface{}) (*Tensor, error) {
+	elemType, ok := tensorElemTypes[dtype]
+	if !ok {
+		return nil, fmt.Errorf("unsupported dtype %v", dtype)
+	}
+	value := reflect.ValueOf(data)
+	if value.Kind() != reflect.Slice || value.Type().Elem() != elemType {
+		return nil, fmt.Errorf("dtype %v expects data of []%v, got %T", dtype, elemType, data)
+	}
+	count, err := shapeSize(shape)
+	if err != nil {
+		return nil, err
+	}
+	if count != value.Len() {
+		return nil, fmt.Errorf("shape %v expects %d elements, got %d", shape, count, value.Len())
+	}
+	return &Tensor{dtype: dtype, shape: append([]int64{}, shape...), data: value}, nil
+}
+
+// TensorFromSlice converts nested Go slices or arrays, e.g. [][]float32, into a tensor, the dtype is
+// inferred from the element type, int is converted to DT_INT64 and string to DT_STRING. Ragged slices
+// are rejected, and a non-slice value becomes a scalar tensor.
+func TensorFromSlice(value interface{}) (*Tensor, error) {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() {
+		return nil, fmt.Errorf("nil value can not be converted to tensor")
+	}
+	var shape []int64
+	t := v.Type()
+	for t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	for e := v; e.Kind() == reflect.Slice || e.Kind() == reflect.Array; {
+		shape = append(shape, int64(e.Len()))
+		if e.Len() == 0 {
+			break
+		}
+		e = e.Index(0)
+	}
+	dtype, ok := goTypeDtypes[t.Kind()]
+	if !ok {
+		return nil, fmt.Errorf("unsupported element type %v", t)
+	}
+	count, _ := shapeSize(shape)
+	data := reflect.MakeSlice(reflect.SliceOf(tensorElemTypes[dtype]), 0, count)
+	if err := flattenSlice(v, shape, &data); err != nil {
+		return nil, err
+	}
+	return &Tensor{dtype: dtype, shape: shape, data: data}, nil
+}
+
+// Dtype returns the dtype of tensor.
+func (t *Tensor) Dtype() tf_predict_protos.ArrayDataType {
+	return t.dtype
+}
+
+// Shape returns the shape of tensor.
+func (t *Tensor) Shape() []int64 {
+	return append([]int64{}, t.shape...)
+}
+
+// Size returns the number of elements in tensor.
+func (t *Tensor) Size() int {
+	return t.data.Len()
+}
+
+// Data returns the flat backing buffer of tensor, e.g. []float32 for DT_FLOAT.
+func (t *Tensor) Data() interface{} {
+	return t.data.Interface()
+}
+
+// At returns the element at the indices, one per dimension. It panics if the indices are out of range.
+func (t *Tensor) At(indices ...int) interface{} {
+	if len(indices) != len(t.shape) {
+		panic(fmt.Sprintf("eas: %d indices for tensor of shape %v", len(indices), t.shape))
+	}
+	offset := 0
+	for i, index := range indices {
+		if index < 0 || int64(index) >= t.shape[i] {
+			panic(fmt.Sprintf("eas: index %v out of range of shape %v", indices, t.shape))
+		}
+		offset = offset*int(t.shape[i]) + index
+	}
+	return t.data.Index(offset).Interface()
+}
+
+// Reshape returns a tensor of the same buffer viewed in another shape, one dim can be -1 to be inferred.
+func (t *Tensor) Reshape(shape ...int64) (*Tensor, error) {
+	shape = append([]int64{}, shape...)
+	inferred := -1
+	known := int64(1)
+	for i, dim := range shape {
+		switch {
+		case dim == -1 && inferred < 0:
+			inferred = i
+		case dim < 0:
+			return nil, fmt.Errorf("invalid shape %v", shape)
+		default:
+			known *= dim
+		}
+	}
+	if inferred >= 0 {
+		if known == 0 || int64(t.Size())%known != 0 {
+			return nil, fmt.Errorf("can not reshape %v into %v", t.shape, shape)
+		}
+		shape[inferred] = int64(t.Size()) / known
+	} else if known != int64(t.Size()) {
+		return nil, fmt.Errorf("can not reshape %v into %v", t.shape, shape)
+	}
+	return &Tensor{dtype: t.dtype, shape: shape, data: t.data}, nil
+}
+
+// Slice returns the tensor of rows [start, end) along the batch axis, sharing the buffer.
+func (t *Tensor) Slice(start, end int) (*Tensor, error) {
+	if len(t.shape) == 0 {
+		return nil, fmt.Errorf("scalar tensor can not be sliced")
+	}
+	if start < 0 || end < start || int64(end) > t.shape[0] {
+		return nil, fmt.Errorf("slice [%d:%d] out of range of batch size %d", start, end, t.shape[0])
+	}
+	stride := 1
+	for _, dim := range t.shape[1:] {
+		stride *= int(dim)
+	}
+	shape := append([]int64{int64(end - start)}, t.shape[1:]...)
+	return &Tensor{dtype: t.dtype, shape: shape, data: t.data.Slice(start*stride, end*stride)}, nil
+}
+
+// ToSlice converts the tensor into nested Go slices, e.g. [][]float32 for DT_FLOAT tensor of 2 dims,
+// DT_STRING elements are converted to string. A scalar tensor returns the element itself.
+func (t *Tensor) ToSlice() interface{} {
+	elemType := t.data.Type().Elem()
+	if t.dtype == TfType_DT_STRING {
+		elemType = reflect.TypeOf("")
+	}
+	nested, _ := nestSlice(t.data, elemType, t.shape)
+	return nested.Interface()
+}
+
+func nestSlice(data reflect.Value, elemType reflect.Type, shape []int64) (reflect.Value, reflect.Value) {
+	if len(shape) == 0 {
+		return data.Index(0).Convert(elemType), data.Slice(1, data.Len())
+	}
+	sliceType := elemType
+	for range shape {
+		sliceType = reflect.SliceOf(sliceType)
+	}
+	ret := reflect.MakeSlice(sliceType, int(shape[0]), int(shape[0]))
+	for i := 0; i < int(shape[0]); i++ {
+		var item reflect.Value
+		item, data = nestSlice(data, elemType, shape[1:])
+		ret.Index(i).Set(item)
+	}
+	return ret, data
+}
+
+func flattenSlice(v reflect.Value, shape []int64, data *reflect.Value) error {
+	if len(shape) == 0 {
+		if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+			return fmt.Errorf("ragged slice is not supported")
+		}
+		*data = reflect.Append(*data, v.Convert(data.Type().Elem()))
+		return nil
+	}
+	if (v.Kind() != reflect.Slice && v.Kind() != reflect.Array) || int64(v.Len()) != shape[0] {
+		return fmt.Errorf("ragged slice is not supported")
+	}
+	for i := 0; i < v.Len(); i++ {
+		if err := flattenSlice(v.Index(i), shape[1:], data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func shapeSize(shape []int64) (int, error) {
+	count := int64(1)
+	for _, dim := range shape {
+		if dim < 0 {
+			return 0, fmt.Errorf("negative dim in shape %v", shape)
+		}
+		count *= dim
+	}
+	return int(count), nil
+}
+
+var tensorElemTypes = map[tf_predict_protos.ArrayDataType]reflect.Type{
+	TfType_DT_FLOAT:      reflect.TypeOf(float32(0)),
+	TfType_DT_HALF:       reflect.TypeOf(float32(0)),
+	TfType_DT_BFLOAT16:   reflect.TypeOf(float32(0)),
+	TfType_DT_DOUBLE:     reflect.TypeOf(float64(0)),
+	TfType_DT_INT32:      reflect.TypeOf(int32(0)),
+	TfType_DT_QINT32:     reflect.TypeOf(int32(0)),
+	TfType_DT_INT64:      reflect.TypeOf(int64(0)),
+	TfType_DT_INT8:       reflect.TypeOf(int8(0)),
+	TfType_DT_QINT8:      reflect.TypeOf(int8(0)),
+	TfType_DT_UINT8:      reflect.TypeOf(uint8(0)),
+	TfType_DT_QUINT8:     reflect.TypeOf(uint8(0)),
+	TfType_DT_INT16:      reflect.TypeOf(int16(0)),
+	TfType_DT_QINT16:     reflect.TypeOf(int16(0)),
+	TfType_DT_UINT16:     reflect.TypeOf(uint16(0)),
+	TfType_DT_QUINT16:    reflect.TypeOf(uint16(0)),
+	TfType_DT_BOOL:       reflect.TypeOf(false),
+	TfType_DT_STRING:     reflect.TypeOf([]byte{}),
+	TfType_DT_COMPLEX64:  reflect.TypeOf(complex64(0)),
+	TfType_DT_COMPLEX128: reflect.TypeOf(complex128(0)),
+}
+
+var goTypeDtypes = map[reflect.Kind]tf_predict_protos.ArrayDataType{
+	reflect.Float32:    TfType_DT_FLOAT,
+	reflect.Float64:    TfType_DT_DOUBLE,
+	reflect.Int32:      TfType_DT_INT32,
+	reflect.Int64:      TfType_DT_INT64,
+	reflect.Int:        TfType_DT_INT64,
+	reflect.Int8:       TfType_DT_INT8,
+	reflect.Uint8:      TfType_DT_UINT8,
+	reflect.Int16:      TfType_DT_INT16,
+	reflect.Uint16:     TfType_DT_UINT16,
+	reflect.Bool:       TfType_DT_BOOL,
+	reflect.String:     TfType_DT_STRING,
+	reflect.Complex64:  TfType_DT_COMPLEX64,
+	reflect.Complex128: TfType_DT_COMPLEX128,
+}
+
+// arrayValues holds the value fields shared by the ArrayProto of TensorFlow and PyTorch.
+type arrayValues struct {
+	floatVal  []float32
+	doubleVal []float64
+	intVal    []int32
+	int64Val  []int64
+	stringVal [][]byte
+	boolVal   []bool
+}
+
+// values packs the buffer into the value fields of ArrayProto, bool is packed into intVal
+// if boolAsInt is set, as PyTorch ArrayProto has no bool_val.
+func (t *Tensor) values(boolAsInt bool) arrayValues {
+	v := arrayValues{}
+	switch data := t.Data().(type) {
+	case []float32:
+		switch t.dtype {
+		case TfType_DT_HALF:
+			v.intVal = packHalf(data)
+		case TfType_DT_BFLOAT16:
+			v.intVal = packBFloat16(data)
+		default:
+			v.floatVal = data
+		}
+	case []float64:
+		v.doubleVal = data
+	case []int32:
+		v.intVal = data
+	case []int64:
+		v.int64Val = data
+	case []int8:
+		v.intVal = packInt8(data)
+	case []uint8:
+		v.intVal = packUint8(data)
+	case []int16:
+		v.intVal = packInt16(data)
+	case []uint16:
+		v.intVal = packUint16(data)
+	case []bool:
+		if boolAsInt {
+			v.intVal = packBool(data)
+		} else {
+			v.boolVal = data
+		}
+	case [][]byte:
+		v.stringVal = data
+	case []complex64:
+		v.floatVal = packComplex64(data)
+	case []complex128:
+		v.doubleVal = packComplex128(data)
+	}
+	return v
+}
+
+// tensorFromValues unpacks the value fields of ArrayProto into a tensor.
+func tensorFromValues(dtype tf_predict_protos.ArrayDataType, shape []int64, v arrayValues, boolAsInt bool) (*Tensor, error) {
+	var data interface{}
+	switch dtype {
+	case TfType_DT_FLOAT:
+		data = v.floatVal
+	case TfType_DT_HALF:
+		data = unpackHalf(v.intVal)
+	case TfType_DT_BFLOAT16:
+		data = unpackBFloat16(v.intVal)
+	case TfType_DT_DOUBLE:
+		data = v.doubleVal
+	case TfType_DT_INT32, TfType_DT_QINT32:
+		data = v.intVal
+	case TfType_DT_INT64:
+		data = v.int64Val
+	case TfType_DT_INT8, TfType_DT_QINT8:
+		data = unpackInt8(v.intVal)
+	case TfType_DT_UINT8, TfType_DT_QUINT8:
+		data = unpackUint8(v.intVal)
+	case TfType_DT_INT16, TfType_DT_QINT16:
+		data = unpackInt16(v.intVal)
+	case TfType_DT_UINT16, TfType_DT_QUINT16:
+		data = unpackUint16(v.intVal)
+	case TfType_DT_BOOL:
+		if boolAsInt {
+			data = unpackBool(v.intVal)
+		} else {
+			data = v.boolVal
+		}
+	case TfType_DT_STRING:
+		data = v.stringVal
+	case TfType_DT_COMPLEX64:
+		data = unpackComplex64(v.floatVal)
+	case TfType_DT_COMPLEX128:
+		data = unpackComplex128(v.doubleVal)
+	default:
+		return nil, fmt.Errorf("unsupported dtype %v", dtype)
+	}
+	value := reflect.ValueOf(data)
+	if value.IsNil() {
+		// the getters of protobuf return nil for empty fields
+		value = reflect.MakeSlice(reflect.SliceOf(tensorElemTypes[dtype]), 0, 0)
+	}
+	return NewTensor(dtype, shape, value.Interface())
+}
+
+// AddFeedTensor function adds the tensor as input data for TFRequest
+func (tr *TFRequest) AddFeedTensor(inputName string, tensor *Tensor) {
+	v := tensor.values(false)
+	tr.addFeed(inputName, &tf_predict_protos.ArrayProto{
+		Dtype:      tensor.dtype,
+		ArrayShape: &tf_predict_protos.ArrayShape{Dim: tensor.Shape()},
+		FloatVal:   v.floatVal,
+		DoubleVal:  v.doubleVal,
+		IntVal:     v.intVal,
+		Int64Val:   v.int64Val,
+		StringVal:  v.stringVal,
+		BoolVal:    v.boolVal,
+	})
+}
+
+// AddFeedTensor function adds the tensor as input data for TorchRequest
+func (tr *TorchRequest) AddFeedTensor(index int, tensor *Tensor) {
+	v := tensor.values(true)
+	tr.addFeed(index, &torch_predict_protos.ArrayProto{
+		Dtype:      torch_predict_protos.ArrayDataType(tensor.dtype),
+		ArrayShape: &torch_predict_protos.ArrayShape{Dim: tensor.Shape()},
+		FloatVal:   v.floatVal,
+		DoubleVal:  v.doubleVal,
+		IntVal:     v.intVal,
+		Int64Val:   v.int64Val,
+		StringVal:  v.stringVal,
+	})
+}
+
+// GetTensor returns the output named outputName as a tensor.
+func (tresp *TFResponse) GetTensor(outputName string) (*Tensor, error) {
+	output, ok := tresp.Output(outputName)
+	if !ok {
+		return nil, fmt.Errorf("output [%s]: %w", outputName, ErrOutputNotFound)
+	}
+	return tensorFromValues(output.Dtype, output.GetArrayShape().GetDim(), arrayValues{
+		floatVal:  output.FloatVal,
+		doubleVal: output.DoubleVal,
+		intVal:    output.IntVal,
+		int64Val:  output.Int64Val,
+		stringVal: output.StringVal,
+		boolVal:   output.BoolVal,
+	}, false)
+}
+
+// GetTensor returns the output at outIndex as a tensor.
+func (resp *TorchResponse) GetTensor(outIndex int) (*Tensor, error) {
+	output, ok := resp.Output(outIndex)
+	if !ok {
+		return nil, fmt.Errorf("output [%d]: %w", outIndex, ErrOutputNotFound)
+	}
+	return tensorFromValues(tf_predict_protos.ArrayDataType(output.Dtype), output.GetArrayShape().GetDim(), arrayValues{
+		floatVal:  output.FloatVal,
+		doubleVal: output.DoubleVal,
+		intVal:    output.IntVal,
+		int64Val:  output.Int64Val,
+		stringVal: output.StringVal,
+	}, true)
+}
@@ -0,0 +1,77 @@
+package eas
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTensorFromSlice(t *testing.T) {
+	tensor, err := TensorFromSlice([][]float32{{1, 2, 3}, {4, 5, 6}})
+	assertNoError(t, err)
+	assertEqual(t, tensor.Dtype(), TfType_DT_FLOAT)
+	if !reflect.DeepEqual(tensor.Shape(), []int64{2, 3}) {
+		t.Fatalf("unexpected shape: %v", tensor.Shape())
+	}
+	assertEqual(t, tensor.At(1, 2), float32(6))
+
+	reshaped, err := tensor.Reshape(3, -1)
+	assertNoError(t, err)
+	if !reflect.DeepEqual(reshaped.ToSlice(), [][]float32{{1, 2}, {3, 4}, {5, 6}}) {
+		t.Fatalf("unexpected reshaped tensor: %v", reshaped.ToSlice())
+	}
+	if _, err = tensor.Reshape(4, -1); err == nil {
+		t.Fatal("incompatible shape should be rejected")
+	}
+
+	row, err := tensor.Slice(1, 2)
+	assertNoError(t, err)
+	if !reflect.DeepEqual(row.ToSlice(), [][]float32{{4, 5, 6}}) {
+		t.Fatalf("unexpected slice: %v", row.ToSlice())
+	}
+
+	if _, err = TensorFromSlice([][]int32{{1}, {2, 3}}); err == nil {
+		t.Fatal("ragged slice should be rejected")
+	}
+	words, err := TensorFromSlice([]string{"a", "b"})
+	assertNoError(t, err)
+	assertEqual(t, words.Dtype(), TfType_DT_STRING)
+	if !reflect.DeepEqual(words.ToSlice(), []string{"a", "b"}) {
+		t.Fatalf("unexpected strings: %v", words.ToSlice())
+	}
+	if _, err = NewTensor(TfType_DT_FLOAT, []int64{2}, []float64{1, 2}); err == nil {
+		t.Fatal("data of another type should be rejected")
+	}
+}
+
+func TestTensorRequestResponse(t *testing.T) {
+	half, err := NewTensor(TfType_DT_HALF, []int64{2, 1}, []float32{0.5, -1})
+	assertNoError(t, err)
+	flags, err := TensorFromSlice([]bool{true, false})
+	assertNoError(t, err)
+
+	tfReq := TFRequest{}
+	tfReq.AddFeedTensor("half", half)
+	tfReq.AddFeedTensor("flags", flags)
+	assertNoError(t, tfReq.Validate())
+	tfResp := TFResponse{}
+	tfResp.Response.Outputs = tfReq.RequestData.Inputs
+	got, err := tfResp.GetTensor("half")
+	assertNoError(t, err)
+	if !reflect.DeepEqual(got.ToSlice(), [][]float32{{0.5}, {-1}}) {
+		t.Fatalf("unexpected tensor: %v", got.ToSlice())
+	}
+
+	torchReq := TorchRequest{}
+	torchReq.AddFeedTensor(0, flags)
+	assertNoError(t, torchReq.Validate())
+	torchResp := TorchResponse{}
+	torchResp.Response.Outputs = torchReq.RequestData.Inputs
+	got, err = torchResp.GetTensor(0)
+	assertNoError(t, err)
+	if !reflect.DeepEqual(got.ToSlice(), []bool{true, false}) {
+		t.Fatalf("unexpected tensor: %v", got.ToSlice())
+	}
+	if _, err = torchResp.GetTensor(1); err == nil {
+		t.Fatal("missing output should be reported")
+	}
+}
@@ -8,13 +8,24 @@ import (
 
 // TfType_DT_INVALID and listed types use ALL_CAPS names here to consist with other language's sdk.
 const (
-	TfType_DT_FLOAT  tf_predict_protos.ArrayDataType = tf_predict_protos.ArrayDataType_DT_FLOAT
-	TfType_DT_DOUBLE tf_predict_protos.ArrayDataType = tf_predict_protos.ArrayDataType_DT_DOUBLE
-	TfType_DT_INT32  tf_predict_protos.ArrayDataType = tf_predict_protos.ArrayDataType_DT_INT32
-	TfType_DT_UINT8  tf_predict_protos.ArrayDataType = tf_predict_protos.ArrayDataType_DT_UINT8
-	TfType_DT_INT16  tf_predict_protos.ArrayDataType = tf_predict_protos.ArrayDataType_DT_INT16
-	TfType_DT_INT8   tf_predict_protos.ArrayDataType = tf_predict_protos.ArrayDataType_DT_INT8
-	TfType_DT_STRING tf_predict_protos.ArrayDataType = tf_predict_protos.ArrayDataType_DT_STRING
-	TfType_DT_INT64  tf_predict_protos.ArrayDataType = tf_predict_protos.ArrayDataType_DT_INT64
-	TfType_DT_BOOL   tf_predict_protos.ArrayDataType = tf_predict_protos.ArrayDataType_DT_BOOL
+	TfType_DT_INVALID    tf_predict_protos.ArrayDataType = tf_predict_protos.ArrayDataType_DT_INVALID
+	TfType_DT_FLOAT      tf_predict_protos.ArrayDataType = tf_predict_protos.ArrayDataType_DT_FLOAT
+	TfType_DT_DOUBLE     tf_predict_protos.ArrayDataType = tf_predict_protos.ArrayDataType_DT_DOUBLE
+	TfType_DT_INT32      tf_predict_protos.ArrayDataType = tf_predict_protos.ArrayDataType_DT_INT32
+	TfType_DT_UINT8      tf_predict_protos.ArrayDataType = tf_predict_protos.ArrayDataType_DT_UINT8
+	TfType_DT_INT16      tf_predict_protos.ArrayDataType = tf_predict_protos.ArrayDataType_DT_INT16
+	TfType_DT_INT8       tf_predict_protos.ArrayDataType = tf_predict_protos.ArrayDataType_DT_INT8
+	TfType_DT_STRING     tf_predict_protos.ArrayDataType = tf_predict_protos.ArrayDataType_DT_STRING
+	TfType_DT_COMPLEX64  tf_predict_protos.ArrayDataType = tf_predict_protos.ArrayDataType_DT_COMPLEX64
+	TfType_DT_INT64      tf_predict_protos.ArrayDataType = tf_predict_protos.ArrayDataType_DT_INT64
+	TfType_DT_BOOL       tf_predict_protos.ArrayDataType = tf_predict_protos.ArrayDataType_DT_BOOL
+	TfType_DT_QINT8      tf_predict_protos.ArrayDataType = tf_predict_protos.ArrayDataType_DT_QINT8
+	TfType_DT_QUINT8     tf_predict_protos.ArrayDataType = tf_predict_protos.ArrayDataType_DT_QUINT8
+	TfType_DT_QINT32     tf_predict_protos.ArrayDataType = tf_predict_protos.ArrayDataType_DT_QINT32
+	TfType_DT_BFLOAT16   tf_predict_protos.ArrayDataType = tf_predict_protos.ArrayDataType_DT_BFLOAT16
+	TfType_DT_QINT16     tf_predict_protos.ArrayDataType = tf_predict_protos.ArrayDataType_DT_QINT16
+	TfType_DT_QUINT16    tf_predict_protos.ArrayDataType = tf_predict_protos.ArrayDataType_DT_QUINT16
+	TfType_DT_UINT16     tf_predict_protos.ArrayDataType = tf_predict_protos.ArrayDataType_DT_UINT16
+	TfType_DT_COMPLEX128 tf_predict_protos.ArrayDataType = tf_predict_protos.ArrayDataType_DT_COMPLEX128
+	TfType_DT_HALF       tf_predict_protos.ArrayDataType = tf_predict_protos.ArrayDataType_DT_HALF
 )
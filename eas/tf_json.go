@@ -0,0 +1,400 @@
+package eas
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pai-eas/eas-golang-sdk/eas/types/tf_predict_protos"
+)
+
+// Formats of TFRequest and TFResponse on the wire.
+const (
+	// TFFormatProtobuf is the PredictRequest protobuf of EAS TensorFlow processor, it's the default.
+	TFFormatProtobuf = ""
+	// TFFormatJSONRow is the row format of TensorFlow Serving REST API, {"instances": [...]}.
+	TFFormatJSONRow = "row"
+	// TFFormatJSONColumnar is the columnar format of TensorFlow Serving REST API, {"inputs": {...}}.
+	TFFormatJSONColumnar = "columnar"
+)
+
+// TFDefaultOutputName is the output name of response in JSON format when TensorFlow Serving omits
+// the name of the only output, unless a single output is fetched by the request.
+const TFDefaultOutputName = "outputs"
+
+type tfJSONRequest struct {
+	SignatureName string      `json:"signature_name,omitempty"`
+	Instances     interface{} `json:"instances,omitempty"`
+	Inputs        interface{} `json:"inputs,omitempty"`
+}
+
+// marshalJSON encodes the request into the row or columnar format of TensorFlow Serving REST API,
+// string tensors are encoded as {"b64": ...}. The output filter is not supported by the REST API.
+func (tr *TFRequest) marshalJSON() ([]byte, error) {
+	req := tfJSONRequest{SignatureName: tr.RequestData.SignatureName}
+	names := make([]string, 0, len(tr.RequestData.Inputs))
+	for name := range tr.RequestData.Inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	switch tr.format {
+	case TFFormatJSONColumnar:
+		inputs := make(map[string]interface{}, len(names))
+		for _, name := range names {
+			nested, err := nestArray(tr.RequestData.Inputs[name])
+			if err != nil {
+				return nil, fmt.Errorf("input %s: %v", name, err)
+			}
+			inputs[name] = nested
+		}
+		req.Inputs = inputs
+	case TFFormatJSONRow:
+		batch := -1
+		columns := make(map[string][]interface{}, len(names))
+		for _, name := range names {
+			nested, err := nestArray(tr.RequestData.Inputs[name])
+			if err != nil {
+				return nil, fmt.Errorf("input %s: %v", name, err)
+			}
+			rows, ok := nested.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("input %s: scalar can not be sent in row format", name)
+			}
+			if batch >= 0 && len(rows) != batch {
+				return nil, fmt.Errorf("input %s: batch size %d differs from %d of other inputs", name, len(rows), batch)
+			}
+			batch = len(rows)
+			columns[name] = rows
+		}
+		instances := make([]interface{}, 0, batch)
+		for i := 0; i < batch; i++ {
+			if len(names) == 1 {
+				instances = append(instances, columns[names[0]][i])
+				continue
+			}
+			instance := make(map[string]interface{}, len(names))
+			for _, name := range names {
+				instance[name] = columns[name][i]
+			}
+			instances = append(instances, instance)
+		}
+		req.Instances = instances
+	default:
+		return nil, fmt.Errorf("unknown format: %s", tr.format)
+	}
+	return json.Marshal(&req)
+}
+
+// nestArray converts the flat content of array into nested slices according to its shape.
+func nestArray(array *tf_predict_protos.ArrayProto) (interface{}, error) {
+	var values []interface{}
+	switch array.Dtype {
+	case TfType_DT_FLOAT:
+		for _, v := range array.FloatVal {
+			values = append(values, v)
+		}
+	case TfType_DT_DOUBLE:
+		for _, v := range array.DoubleVal {
+			values = append(values, v)
+		}
+	case TfType_DT_INT32, TfType_DT_INT16, TfType_DT_INT8, TfType_DT_UINT8:
+		for _, v := range array.IntVal {
+			values = append(values, v)
+		}
+	case TfType_DT_INT64:
+		for _, v := range array.Int64Val {
+			values = append(values, v)
+		}
+	case TfType_DT_BOOL:
+		for _, v := range array.BoolVal {
+			values = append(values, v)
+		}
+	case TfType_DT_STRING:
+		for _, v := range array.StringVal {
+			values = append(values, map[string]string{"b64": base64.StdEncoding.EncodeToString(v)})
+		}
+	default:
+		return nil, fmt.Errorf("unsupported dtype %v in JSON format", array.Dtype)
+	}
+	shape := array.GetArrayShape().GetDim()
+	count := int64(1)
+	for _, dim := range shape {
+		count *= dim
+	}
+	if count != int64(len(values)) {
+		return nil, fmt.Errorf("shape %v does not match %d elements", shape, len(values))
+	}
+	if len(shape) == 0 {
+		return values[0], nil
+	}
+	nested, _ := nestValues(values, shape)
+	return nested, nil
+}
+
+func nestValues(values []interface{}, shape []int64) (interface{}, []interface{}) {
+	if len(shape) == 0 {
+		return values[0], values[1:]
+	}
+	ret := make([]interface{}, 0, shape[0])
+	for i := int64(0); i < shape[0]; i++ {
+		var item interface{}
+		item, values = nestValues(values, shape[1:])
+		ret = append(ret, item)
+	}
+	return ret, values
+}
+
+// unmarshalJSON decodes the response of TensorFlow Serving REST API in row format {"predictions": ...}
+// or columnar format {"outputs": ...}. The dtype of outputs is inferred from the JSON values: strings
+// and {"b64": ...} are DT_STRING, booleans are DT_BOOL, numbers are DT_FLOAT if any of them has a
+// fraction or exponent part, and DT_INT64 otherwise. Since a float output may be written without
+// fractions, the float accessors of response read number outputs of both dtypes.
+func (tresp *TFResponse) unmarshalJSON(body []byte) error {
+	resp := struct {
+		Predictions json.RawMessage `json:"predictions"`
+		Outputs     json.RawMessage `json:"outputs"`
+		Error       string          `json:"error"`
+	}{}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return err
+	}
+	if len(resp.Error) != 0 {
+		return fmt.Errorf("tensorflow serving error: %s", resp.Error)
+	}
+	outputs := map[string]*tf_predict_protos.ArrayProto{}
+	switch {
+	case len(resp.Predictions) != 0:
+		rows, err := decodeJSONValue(resp.Predictions)
+		if err != nil {
+			return err
+		}
+		list, ok := rows.([]interface{})
+		if !ok {
+			return fmt.Errorf("predictions should be a list")
+		}
+		if len(list) > 0 && isNamedOutputs(list[0]) {
+			columns := map[string][]interface{}{}
+			for _, row := range list {
+				named, ok := row.(map[string]interface{})
+				if !ok {
+					return fmt.Errorf("unexpected prediction: %v", row)
+				}
+				for name, value := range named {
+					columns[name] = append(columns[name], value)
+				}
+			}
+			for name, column := range columns {
+				if outputs[name], err = arrayFromJSON(column); err != nil {
+					return fmt.Errorf("output %s: %v", name, err)
+				}
+			}
+		} else {
+			array, err := arrayFromJSON(list)
+			if err != nil {
+				return err
+			}
+			outputs[tresp.defaultOutputName()] = array
+		}
+	case len(resp.Outputs) != 0:
+		value, err := decodeJSONValue(resp.Outputs)
+		if err != nil {
+			return err
+		}
+		if isNamedOutputs(value) {
+			for name, v := range value.(map[string]interface{}) {
+				if outputs[name], err = arrayFromJSON(v); err != nil {
+					return fmt.Errorf("output %s: %v", name, err)
+				}
+			}
+		} else {
+			array, err := arrayFromJSON(value)
+			if err != nil {
+				return err
+			}
+			outputs[tresp.defaultOutputName()] = array
+		}
+	default:
+		return fmt.Errorf("neither predictions nor outputs found in response")
+	}
+	tresp.Response = tf_predict_protos.PredictResponse{Outputs: outputs}
+	return nil
+}
+
+func (tresp *TFResponse) defaultOutputName() string {
+	if len(tresp.fetches) == 1 {
+		return tresp.fetches[0]
+	}
+	return TFDefaultOutputName
+}
+
+func decodeJSONValue(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// isNamedOutputs checks whether the value is a map of outputs rather than a base64 string.
+func isNamedOutputs(value interface{}) bool {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, isB64 := m["b64"]
+	return !(isB64 && len(m) == 1)
+}
+
+// arrayFromJSON infers the shape and dtype of nested JSON values and flattens them into an array.
+func arrayFromJSON(value interface{}) (*tf_predict_protos.ArrayProto, error) {
+	var shape []int64
+	for v := value; ; {
+		list, ok := v.([]interface{})
+		if !ok {
+			break
+		}
+		shape = append(shape, int64(len(list)))
+		if len(list) == 0 {
+			break
+		}
+		v = list[0]
+	}
+	var flat []interface{}
+	if err := flattenJSON(value, shape, &flat); err != nil {
+		return nil, err
+	}
+
+	array := &tf_predict_protos.ArrayProto{ArrayShape: &tf_predict_protos.ArrayShape{Dim: shape}}
+	if len(flat) == 0 {
+		array.Dtype = TfType_DT_FLOAT
+		return array, nil
+	}
+	switch flat[0].(type) {
+	case string, map[string]interface{}:
+		array.Dtype = TfType_DT_STRING
+		for _, v := range flat {
+			s, err := jsonString(v)
+			if err != nil {
+				return nil, err
+			}
+			array.StringVal = append(array.StringVal, s)
+		}
+	case bool:
+		array.Dtype = TfType_DT_BOOL
+		for _, v := range flat {
+			b, ok := v.(bool)
+			if !ok {
+				return nil, fmt.Errorf("mixed types of %v and %v", flat[0], v)
+			}
+			array.BoolVal = append(array.BoolVal, b)
+		}
+	case json.Number:
+		fractional := false
+		for _, v := range flat {
+			n, ok := v.(json.Number)
+			if !ok {
+				return nil, fmt.Errorf("mixed types of %v and %v", flat[0], v)
+			}
+			if strings.ContainsAny(n.String(), ".eE") {
+				fractional = true
+			}
+		}
+		if fractional {
+			array.Dtype = TfType_DT_FLOAT
+			for _, v := range flat {
+				f, err := v.(json.Number).Float64()
+				if err != nil {
+					return nil, err
+				}
+				array.FloatVal = append(array.FloatVal, float32(f))
+			}
+		} else {
+			array.Dtype = TfType_DT_INT64
+			for _, v := range flat {
+				i, err := v.(json.Number).Int64()
+				if err != nil {
+					return nil, err
+				}
+				array.Int64Val = append(array.Int64Val, i)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported value %v", flat[0])
+	}
+	return array, nil
+}
+
+// jsonInferred tells whether output is a number array of JSON response, whose dtype is inferred from the
+// formatting of numbers rather than known, e.g. [[1, 0]] of a float output is DT_INT64.
+func (tresp *TFResponse) jsonInferred(output *tf_predict_protos.ArrayProto) bool {
+	return tresp.format != TFFormatProtobuf && output != nil &&
+		(output.Dtype == TfType_DT_FLOAT || output.Dtype == TfType_DT_INT64)
+}
+
+// jsonFloats returns the values of number array decoded from JSON as float32.
+func jsonFloats(output *tf_predict_protos.ArrayProto) []float32 {
+	if output.Dtype == TfType_DT_FLOAT {
+		return output.GetFloatVal()
+	}
+	values := make([]float32, len(output.GetInt64Val()))
+	for i, v := range output.GetInt64Val() {
+		values[i] = float32(v)
+	}
+	return values
+}
+
+// jsonDoubles returns the values of number array decoded from JSON as float64, the fractional ones
+// are of the precision of float32.
+func jsonDoubles(output *tf_predict_protos.ArrayProto) []float64 {
+	if output.Dtype == TfType_DT_FLOAT {
+		values := make([]float64, len(output.GetFloatVal()))
+		for i, v := range output.GetFloatVal() {
+			values[i] = float64(v)
+		}
+		return values
+	}
+	values := make([]float64, len(output.GetInt64Val()))
+	for i, v := range output.GetInt64Val() {
+		values[i] = float64(v)
+	}
+	return values
+}
+
+// flattenJSON flattens nested values, failing on ragged arrays which do not match the shape.
+func flattenJSON(value interface{}, shape []int64, flat *[]interface{}) error {
+	list, ok := value.([]interface{})
+	if len(shape) == 0 {
+		if ok {
+			return fmt.Errorf("ragged array is not supported")
+		}
+		*flat = append(*flat, value)
+		return nil
+	}
+	if !ok || int64(len(list)) != shape[0] {
+		return fmt.Errorf("ragged array is not supported")
+	}
+	for _, item := range list {
+		if err := flattenJSON(item, shape[1:], flat); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func jsonString(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case string:
+		return []byte(v), nil
+	case map[string]interface{}:
+		if b64, ok := v["b64"].(string); ok {
+			return base64.StdEncoding.DecodeString(b64)
+		}
+	}
+	return nil, fmt.Errorf("unexpected string value %v", value)
+}
@@ -0,0 +1,98 @@
+package eas
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestTFRequestJSONFormats(t *testing.T) {
+	req := TFRequest{}
+	req.SetSignatureName("serving_default")
+	req.AddFeedFloat32("x", []int64{2, 2}, []float32{1, 2, 3, 4})
+	req.AddFeedString("s", []int64{2}, [][]byte{[]byte("a"), []byte("b")})
+
+	req.SetFormat(TFFormatJSONRow)
+	data, err := req.ToBytes()
+	assertNoError(t, err)
+	assertEqual(t, string(data), `{"signature_name":"serving_default","instances":[`+
+		`{"s":{"b64":"YQ=="},"x":[1,2]},{"s":{"b64":"Yg=="},"x":[3,4]}]}`)
+
+	req.SetFormat(TFFormatJSONColumnar)
+	data, err = req.ToBytes()
+	assertNoError(t, err)
+	assertEqual(t, string(data), `{"signature_name":"serving_default","inputs":`+
+		`{"s":[{"b64":"YQ=="},{"b64":"Yg=="}],"x":[[1,2],[3,4]]}}`)
+
+	req.AddFeedInt32("y", []int64{3}, []int32{1, 2, 3})
+	req.SetFormat(TFFormatJSONRow)
+	if _, err = req.ToBytes(); err == nil {
+		t.Fatal("inputs of different batch sizes should be rejected in row format")
+	}
+}
+
+func TestTFResponseJSON(t *testing.T) {
+	resp := TFResponse{format: TFFormatJSONRow}
+	assertNoError(t, resp.unmarshal([]byte(`{"predictions": [{"scores": [0.5, 1.0], "classes": 3, "name": {"b64": "Y2F0"}},
+		{"scores": [0.25, 2e-1], "classes": 7, "name": {"b64": "ZG9n"}}]}`)))
+	if !reflect.DeepEqual(resp.GetFloatVal("scores"), []float32{0.5, 1, 0.25, 0.2}) {
+		t.Fatalf("unexpected scores: %v", resp.GetFloatVal("scores"))
+	}
+	if !reflect.DeepEqual(resp.GetTensorShape("scores"), []int64{2, 2}) {
+		t.Fatalf("unexpected shape: %v", resp.GetTensorShape("scores"))
+	}
+	if !reflect.DeepEqual(resp.GetInt64Val("classes"), []int64{3, 7}) {
+		t.Fatalf("unexpected classes: %v", resp.GetInt64Val("classes"))
+	}
+	assertEqual(t, string(resp.GetStringVal("name")[1]), "dog")
+
+	// float outputs written without fractions are read by the float accessors
+	resp = TFResponse{format: TFFormatJSONRow}
+	assertNoError(t, resp.unmarshal([]byte(`{"predictions": [[1, 0]]}`)))
+	probs, err := resp.FloatVal(TFDefaultOutputName)
+	assertNoError(t, err)
+	doubles, err := resp.DoubleVal(TFDefaultOutputName)
+	assertNoError(t, err)
+	classes, err := resp.Int64Val(TFDefaultOutputName)
+	assertNoError(t, err)
+	if !reflect.DeepEqual(probs, []float32{1, 0}) || !reflect.DeepEqual(resp.GetFloatVal(TFDefaultOutputName), probs) ||
+		!reflect.DeepEqual(doubles, []float64{1, 0}) || !reflect.DeepEqual(classes, []int64{1, 0}) {
+		t.Fatalf("unexpected outputs: %v %v %v", probs, doubles, classes)
+	}
+
+	resp = TFResponse{format: TFFormatJSONColumnar, fetches: []string{"probs"}}
+	assertNoError(t, resp.unmarshal([]byte(`{"outputs": [[0.1, 0.9], [0.8, 0.2]]}`)))
+	if !reflect.DeepEqual(resp.GetTensorShape("probs"), []int64{2, 2}) {
+		t.Fatalf("unexpected shape: %v", resp.GetTensorShape("probs"))
+	}
+
+	resp = TFResponse{format: TFFormatJSONColumnar}
+	if err := resp.unmarshal([]byte(`{"outputs": [[1], [2, 3]]}`)); err == nil {
+		t.Fatal("ragged array should be rejected")
+	}
+}
+
+func TestTFPredictJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected content type: %s", r.Header.Get("Content-Type"))
+		}
+		req := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Write([]byte(`{"predictions": [[0.5, 0.5]]}`))
+	}))
+	defer server.Close()
+	client := NewPredictClient(server.Listener.Addr().String(), "tf_serving")
+	client.Init()
+
+	req := TFRequest{}
+	req.SetFormat(TFFormatJSONRow)
+	req.AddFeedFloat32("images", []int64{1, 2}, []float32{0, 1})
+	resp, err := client.TFPredict(req)
+	assertNoError(t, err)
+	if !reflect.DeepEqual(resp.GetFloatVal(TFDefaultOutputName), []float32{0.5, 0.5}) {
+		t.Fatalf("unexpected output: %v", resp.GetFloatVal(TFDefaultOutputName))
+	}
+}
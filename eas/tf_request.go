@@ -1,13 +1,14 @@
 package eas
 
 import (
-	"github.com/golang/protobuf/proto"
 	"github.com/pai-eas/eas-golang-sdk/eas/types/tf_predict_protos"
 )
 
 // TFRequest class for tensorflow data and requests
 type TFRequest struct {
 	RequestData tf_predict_protos.PredictRequest
+
+	format string
 }
 
 // SetSignatureName set signature name for TensorFlow request
@@ -15,6 +16,12 @@ func (tr *TFRequest) SetSignatureName(sigName string) {
 	tr.RequestData.SignatureName = sigName
 }
 
+// SetFormat selects the wire format of the request and its response, TFFormatProtobuf by default,
+// TFFormatJSONRow and TFFormatJSONColumnar are the JSON formats of TensorFlow Serving REST API
+func (tr *TFRequest) SetFormat(format string) {
+	tr.format = format
+}
+
 // AddFeedFloat32 function adds float values input data for TFRequest
 func (tr *TFRequest) AddFeedFloat32(inputName string, shape []int64, content []float32) {
 	requestProto := tf_predict_protos.ArrayProto{
@@ -105,6 +112,130 @@ func (tr *TFRequest) AddFeedString(inputName string, shape []int64, content [][]
 	tr.RequestData.Inputs[inputName] = &requestProto
 }
 
+// AddFeedUint8 function adds uint8 values input data for TFRequest
+func (tr *TFRequest) AddFeedUint8(inputName string, shape []int64, content []uint8) {
+	tr.addFeed(inputName, &tf_predict_protos.ArrayProto{
+		Dtype:      TfType_DT_UINT8,
+		ArrayShape: &tf_predict_protos.ArrayShape{Dim: shape},
+		IntVal:     packUint8(content),
+	})
+}
+
+// AddFeedInt8 function adds int8 values input data for TFRequest
+func (tr *TFRequest) AddFeedInt8(inputName string, shape []int64, content []int8) {
+	tr.addFeed(inputName, &tf_predict_protos.ArrayProto{
+		Dtype:      TfType_DT_INT8,
+		ArrayShape: &tf_predict_protos.ArrayShape{Dim: shape},
+		IntVal:     packInt8(content),
+	})
+}
+
+// AddFeedInt16 function adds int16 values input data for TFRequest
+func (tr *TFRequest) AddFeedInt16(inputName string, shape []int64, content []int16) {
+	tr.addFeed(inputName, &tf_predict_protos.ArrayProto{
+		Dtype:      TfType_DT_INT16,
+		ArrayShape: &tf_predict_protos.ArrayShape{Dim: shape},
+		IntVal:     packInt16(content),
+	})
+}
+
+// AddFeedUint16 function adds uint16 values input data for TFRequest
+func (tr *TFRequest) AddFeedUint16(inputName string, shape []int64, content []uint16) {
+	tr.addFeed(inputName, &tf_predict_protos.ArrayProto{
+		Dtype:      TfType_DT_UINT16,
+		ArrayShape: &tf_predict_protos.ArrayShape{Dim: shape},
+		IntVal:     packUint16(content),
+	})
+}
+
+// AddFeedHalf function adds half precision float values converted from float32 input data for TFRequest
+func (tr *TFRequest) AddFeedHalf(inputName string, shape []int64, content []float32) {
+	tr.addFeed(inputName, &tf_predict_protos.ArrayProto{
+		Dtype:      TfType_DT_HALF,
+		ArrayShape: &tf_predict_protos.ArrayShape{Dim: shape},
+		IntVal:     packHalf(content),
+	})
+}
+
+// AddFeedBFloat16 function adds bfloat16 values converted from float32 input data for TFRequest
+func (tr *TFRequest) AddFeedBFloat16(inputName string, shape []int64, content []float32) {
+	tr.addFeed(inputName, &tf_predict_protos.ArrayProto{
+		Dtype:      TfType_DT_BFLOAT16,
+		ArrayShape: &tf_predict_protos.ArrayShape{Dim: shape},
+		IntVal:     packBFloat16(content),
+	})
+}
+
+// AddFeedComplex64 function adds single-precision complex values input data for TFRequest
+func (tr *TFRequest) AddFeedComplex64(inputName string, shape []int64, content []complex64) {
+	tr.addFeed(inputName, &tf_predict_protos.ArrayProto{
+		Dtype:      TfType_DT_COMPLEX64,
+		ArrayShape: &tf_predict_protos.ArrayShape{Dim: shape},
+		FloatVal:   packComplex64(content),
+	})
+}
+
+// AddFeedComplex128 function adds double-precision complex values input data for TFRequest
+func (tr *TFRequest) AddFeedComplex128(inputName string, shape []int64, content []complex128) {
+	tr.addFeed(inputName, &tf_predict_protos.ArrayProto{
+		Dtype:      TfType_DT_COMPLEX128,
+		ArrayShape: &tf_predict_protos.ArrayShape{Dim: shape},
+		DoubleVal:  packComplex128(content),
+	})
+}
+
+// AddFeedQInt8 function adds quantized int8 values input data for TFRequest
+func (tr *TFRequest) AddFeedQInt8(inputName string, shape []int64, content []int8) {
+	tr.addFeed(inputName, &tf_predict_protos.ArrayProto{
+		Dtype:      TfType_DT_QINT8,
+		ArrayShape: &tf_predict_protos.ArrayShape{Dim: shape},
+		IntVal:     packInt8(content),
+	})
+}
+
+// AddFeedQUint8 function adds quantized uint8 values input data for TFRequest
+func (tr *TFRequest) AddFeedQUint8(inputName string, shape []int64, content []uint8) {
+	tr.addFeed(inputName, &tf_predict_protos.ArrayProto{
+		Dtype:      TfType_DT_QUINT8,
+		ArrayShape: &tf_predict_protos.ArrayShape{Dim: shape},
+		IntVal:     packUint8(content),
+	})
+}
+
+// AddFeedQInt16 function adds quantized int16 values input data for TFRequest
+func (tr *TFRequest) AddFeedQInt16(inputName string, shape []int64, content []int16) {
+	tr.addFeed(inputName, &tf_predict_protos.ArrayProto{
+		Dtype:      TfType_DT_QINT16,
+		ArrayShape: &tf_predict_protos.ArrayShape{Dim: shape},
+		IntVal:     packInt16(content),
+	})
+}
+
+// AddFeedQUint16 function adds quantized uint16 values input data for TFRequest
+func (tr *TFRequest) AddFeedQUint16(inputName string, shape []int64, content []uint16) {
+	tr.addFeed(inputName, &tf_predict_protos.ArrayProto{
+		Dtype:      TfType_DT_QUINT16,
+		ArrayShape: &tf_predict_protos.ArrayShape{Dim: shape},
+		IntVal:     packUint16(content),
+	})
+}
+
+// AddFeedQInt32 function adds quantized int32 values input data for TFRequest
+func (tr *TFRequest) AddFeedQInt32(inputName string, shape []int64, content []int32) {
+	tr.addFeed(inputName, &tf_predict_protos.ArrayProto{
+		Dtype:      TfType_DT_QINT32,
+		ArrayShape: &tf_predict_protos.ArrayShape{Dim: shape},
+		IntVal:     content,
+	})
+}
+
+func (tr *TFRequest) addFeed(inputName string, requestProto *tf_predict_protos.ArrayProto) {
+	if tr.RequestData.Inputs == nil {
+		tr.RequestData.Inputs = make(map[string]*tf_predict_protos.ArrayProto)
+	}
+	tr.RequestData.Inputs[inputName] = requestProto
+}
+
 // AddFetch adds output filter (outname) for TensorFlow request
 func (tr *TFRequest) AddFetch(outName string) {
 	tr.RequestData.OutputFilter = append(tr.RequestData.OutputFilter, outName)
@@ -112,32 +243,56 @@ func (tr *TFRequest) AddFetch(outName string) {
 
 // ToString for interface
 func (tr TFRequest) ToString() (string, error) {
-	reqdata, err := proto.Marshal(&tr.RequestData)
+	reqData, err := tr.ToBytes()
 	if err != nil {
-		return "", NewPredictError(-1, "", err.Error())
+		return "", err
+	}
+	return string(reqData), nil
+}
+
+// ToBytes serializes the request into protobuf bytes without the string conversion
+func (tr TFRequest) ToBytes() ([]byte, error) {
+	if tr.format != TFFormatProtobuf {
+		reqData, err := tr.marshalJSON()
+		if err != nil {
+			return nil, NewPredictError(-1, "", err.Error())
+		}
+		return reqData, nil
 	}
-	return string(reqdata), nil
+	return tr.appendProto(make([]byte, 0, tr.protoSize())), nil
 }
 
 // TFResponse class for Pytf predicted results
 type TFResponse struct {
 	Response tf_predict_protos.PredictResponse
+
+	format  string
+	fetches []string
 }
 
 // GetTensorShape returns []int64 slice as shape of tensor outindexed
 func (tresp *TFResponse) GetTensorShape(outputName string) []int64 {
-	// return tresp.PredictResponse.Outputs[outputName].ArrayShape.Dim
-	return tresp.Response.Outputs[outputName].ArrayShape.Dim
+	return tresp.Response.Outputs[outputName].GetArrayShape().GetDim()
 }
 
-// GetFloatVal returns []float32 slice as output data
+// GetFloatVal returns []float32 slice as output data, outputs of JSON responses are converted from
+// integers if all their values are written without fractions.
 func (tresp *TFResponse) GetFloatVal(outputName string) []float32 {
-	return tresp.Response.Outputs[outputName].GetFloatVal()
+	output := tresp.Response.Outputs[outputName]
+	if tresp.jsonInferred(output) {
+		return jsonFloats(output)
+	}
+	return output.GetFloatVal()
 }
 
-// GetDoubleVal returns []float64 slice as output data
+// GetDoubleVal returns []float64 slice as output data, outputs of JSON responses are converted from
+// the inferred DT_FLOAT or DT_INT64.
 func (tresp *TFResponse) GetDoubleVal(outputName string) []float64 {
-	return tresp.Response.Outputs[outputName].GetDoubleVal()
+	output := tresp.Response.Outputs[outputName]
+	if tresp.jsonInferred(output) {
+		return jsonDoubles(output)
+	}
+	return output.GetDoubleVal()
 }
 
 // GetIntVal returns []int32 slice as output data
@@ -160,13 +315,58 @@ func (tresp *TFResponse) GetStringVal(outputName string) [][]byte {
 	return tresp.Response.Outputs[outputName].GetStringVal()
 }
 
+// GetUint8Val returns []uint8 slice as output data of DT_UINT8 or DT_QUINT8
+func (tresp *TFResponse) GetUint8Val(outputName string) []uint8 {
+	output := tresp.Response.Outputs[outputName]
+	return unpackUint8(output.GetIntVal())
+}
+
+// GetInt8Val returns []int8 slice as output data of DT_INT8 or DT_QINT8
+func (tresp *TFResponse) GetInt8Val(outputName string) []int8 {
+	output := tresp.Response.Outputs[outputName]
+	return unpackInt8(output.GetIntVal())
+}
+
+// GetInt16Val returns []int16 slice as output data of DT_INT16 or DT_QINT16
+func (tresp *TFResponse) GetInt16Val(outputName string) []int16 {
+	output := tresp.Response.Outputs[outputName]
+	return unpackInt16(output.GetIntVal())
+}
+
+// GetUint16Val returns []uint16 slice as output data of DT_UINT16 or DT_QUINT16
+func (tresp *TFResponse) GetUint16Val(outputName string) []uint16 {
+	output := tresp.Response.Outputs[outputName]
+	return unpackUint16(output.GetIntVal())
+}
+
+// GetHalfVal returns []float32 slice as output data of DT_HALF converted to float32
+func (tresp *TFResponse) GetHalfVal(outputName string) []float32 {
+	output := tresp.Response.Outputs[outputName]
+	return unpackHalf(output.GetIntVal())
+}
+
+// GetBFloat16Val returns []float32 slice as output data of DT_BFLOAT16 converted to float32
+func (tresp *TFResponse) GetBFloat16Val(outputName string) []float32 {
+	output := tresp.Response.Outputs[outputName]
+	return unpackBFloat16(output.GetIntVal())
+}
+
+// GetComplex64Val returns []complex64 slice as output data of DT_COMPLEX64
+func (tresp *TFResponse) GetComplex64Val(outputName string) []complex64 {
+	output := tresp.Response.Outputs[outputName]
+	return unpackComplex64(output.GetFloatVal())
+}
+
+// GetComplex128Val returns []complex128 slice as output data of DT_COMPLEX128
+func (tresp *TFResponse) GetComplex128Val(outputName string) []complex128 {
+	output := tresp.Response.Outputs[outputName]
+	return unpackComplex128(output.GetDoubleVal())
+}
+
 // Unmarshal for interface
 func (tresp *TFResponse) unmarshal(body []byte) error {
-	bd := &tf_predict_protos.PredictResponse{}
-	err := proto.Unmarshal(body, bd)
-	if err != nil {
-		return err
+	if tresp.format != TFFormatProtobuf {
+		return tresp.unmarshalJSON(body)
 	}
-	tresp.Response = *bd
-	return nil
+	return decodeTFResponse(body, &tresp.Response)
 }
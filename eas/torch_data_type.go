@@ -8,11 +8,24 @@ import (
 
 const (
 	// TorchType_DT_FLOAT and listed types use ALL_CAPS names here to consist with other language's sdk.
-	TorchType_DT_FLOAT  torch_predict_protos.ArrayDataType = torch_predict_protos.ArrayDataType_DT_FLOAT
-	TorchType_DT_DOUBLE torch_predict_protos.ArrayDataType = torch_predict_protos.ArrayDataType_DT_DOUBLE
-	TorchType_DT_INT32  torch_predict_protos.ArrayDataType = torch_predict_protos.ArrayDataType_DT_INT32
-	TorchType_DT_UINT8  torch_predict_protos.ArrayDataType = torch_predict_protos.ArrayDataType_DT_UINT8
-	TorchType_DT_INT16  torch_predict_protos.ArrayDataType = torch_predict_protos.ArrayDataType_DT_INT16
-	TorchType_DT_INT8   torch_predict_protos.ArrayDataType = torch_predict_protos.ArrayDataType_DT_INT8
-	TorchType_DT_INT64  torch_predict_protos.ArrayDataType = torch_predict_protos.ArrayDataType_DT_INT64
+	TorchType_DT_INVALID    torch_predict_protos.ArrayDataType = torch_predict_protos.ArrayDataType_DT_INVALID
+	TorchType_DT_FLOAT      torch_predict_protos.ArrayDataType = torch_predict_protos.ArrayDataType_DT_FLOAT
+	TorchType_DT_DOUBLE     torch_predict_protos.ArrayDataType = torch_predict_protos.ArrayDataType_DT_DOUBLE
+	TorchType_DT_INT32      torch_predict_protos.ArrayDataType = torch_predict_protos.ArrayDataType_DT_INT32
+	TorchType_DT_UINT8      torch_predict_protos.ArrayDataType = torch_predict_protos.ArrayDataType_DT_UINT8
+	TorchType_DT_INT16      torch_predict_protos.ArrayDataType = torch_predict_protos.ArrayDataType_DT_INT16
+	TorchType_DT_INT8       torch_predict_protos.ArrayDataType = torch_predict_protos.ArrayDataType_DT_INT8
+	TorchType_DT_STRING     torch_predict_protos.ArrayDataType = torch_predict_protos.ArrayDataType_DT_STRING
+	TorchType_DT_COMPLEX64  torch_predict_protos.ArrayDataType = torch_predict_protos.ArrayDataType_DT_COMPLEX64
+	TorchType_DT_INT64      torch_predict_protos.ArrayDataType = torch_predict_protos.ArrayDataType_DT_INT64
+	TorchType_DT_BOOL       torch_predict_protos.ArrayDataType = torch_predict_protos.ArrayDataType_DT_BOOL
+	TorchType_DT_QINT8      torch_predict_protos.ArrayDataType = torch_predict_protos.ArrayDataType_DT_QINT8
+	TorchType_DT_QUINT8     torch_predict_protos.ArrayDataType = torch_predict_protos.ArrayDataType_DT_QUINT8
+	TorchType_DT_QINT32     torch_predict_protos.ArrayDataType = torch_predict_protos.ArrayDataType_DT_QINT32
+	TorchType_DT_BFLOAT16   torch_predict_protos.ArrayDataType = torch_predict_protos.ArrayDataType_DT_BFLOAT16
+	TorchType_DT_QINT16     torch_predict_protos.ArrayDataType = torch_predict_protos.ArrayDataType_DT_QINT16
+	TorchType_DT_QUINT16    torch_predict_protos.ArrayDataType = torch_predict_protos.ArrayDataType_DT_QUINT16
+	TorchType_DT_UINT16     torch_predict_protos.ArrayDataType = torch_predict_protos.ArrayDataType_DT_UINT16
+	TorchType_DT_COMPLEX128 torch_predict_protos.ArrayDataType = torch_predict_protos.ArrayDataType_DT_COMPLEX128
+	TorchType_DT_HALF       torch_predict_protos.ArrayDataType = torch_predict_protos.ArrayDataType_DT_HALF
 )
@@ -1,7 +1,6 @@
 package eas
 
 import (
-	"github.com/golang/protobuf/proto"
 	"github.com/pai-eas/eas-golang-sdk/eas/types/torch_predict_protos"
 )
 
@@ -70,6 +69,130 @@ func (tr *TorchRequest) AddFeedInt64(index int, shape []int64, content []int64)
 	tr.RequestData.Inputs[index] = &requestProto
 }
 
+// AddFeedBool function adds boolean values input data for torchrequest
+func (tr *TorchRequest) AddFeedBool(index int, shape []int64, content []bool) {
+	tr.addFeed(index, &torch_predict_protos.ArrayProto{
+		Dtype:      TorchType_DT_BOOL,
+		ArrayShape: &torch_predict_protos.ArrayShape{Dim: shape},
+		IntVal:     packBool(content),
+	})
+}
+
+// AddFeedString function adds string values input data for torchrequest
+func (tr *TorchRequest) AddFeedString(index int, shape []int64, content [][]byte) {
+	tr.addFeed(index, &torch_predict_protos.ArrayProto{
+		Dtype:      TorchType_DT_STRING,
+		ArrayShape: &torch_predict_protos.ArrayShape{Dim: shape},
+		StringVal:  content,
+	})
+}
+
+// AddFeedUint8 function adds uint8 values input data for torchrequest
+func (tr *TorchRequest) AddFeedUint8(index int, shape []int64, content []uint8) {
+	tr.addFeed(index, &torch_predict_protos.ArrayProto{
+		Dtype:      TorchType_DT_UINT8,
+		ArrayShape: &torch_predict_protos.ArrayShape{Dim: shape},
+		IntVal:     packUint8(content),
+	})
+}
+
+// AddFeedInt8 function adds int8 values input data for torchrequest
+func (tr *TorchRequest) AddFeedInt8(index int, shape []int64, content []int8) {
+	tr.addFeed(index, &torch_predict_protos.ArrayProto{
+		Dtype:      TorchType_DT_INT8,
+		ArrayShape: &torch_predict_protos.ArrayShape{Dim: shape},
+		IntVal:     packInt8(content),
+	})
+}
+
+// AddFeedInt16 function adds int16 values input data for torchrequest
+func (tr *TorchRequest) AddFeedInt16(index int, shape []int64, content []int16) {
+	tr.addFeed(index, &torch_predict_protos.ArrayProto{
+		Dtype:      TorchType_DT_INT16,
+		ArrayShape: &torch_predict_protos.ArrayShape{Dim: shape},
+		IntVal:     packInt16(content),
+	})
+}
+
+// AddFeedUint16 function adds uint16 values input data for torchrequest
+func (tr *TorchRequest) AddFeedUint16(index int, shape []int64, content []uint16) {
+	tr.addFeed(index, &torch_predict_protos.ArrayProto{
+		Dtype:      TorchType_DT_UINT16,
+		ArrayShape: &torch_predict_protos.ArrayShape{Dim: shape},
+		IntVal:     packUint16(content),
+	})
+}
+
+// AddFeedHalf function adds half precision float values converted from float32 input data for torchrequest
+func (tr *TorchRequest) AddFeedHalf(index int, shape []int64, content []float32) {
+	tr.addFeed(index, &torch_predict_protos.ArrayProto{
+		Dtype:      TorchType_DT_HALF,
+		ArrayShape: &torch_predict_protos.ArrayShape{Dim: shape},
+		IntVal:     packHalf(content),
+	})
+}
+
+// AddFeedBFloat16 function adds bfloat16 values converted from float32 input data for torchrequest
+func (tr *TorchRequest) AddFeedBFloat16(index int, shape []int64, content []float32) {
+	tr.addFeed(index, &torch_predict_protos.ArrayProto{
+		Dtype:      TorchType_DT_BFLOAT16,
+		ArrayShape: &torch_predict_protos.ArrayShape{Dim: shape},
+		IntVal:     packBFloat16(content),
+	})
+}
+
+// AddFeedComplex64 function adds single-precision complex values input data for torchrequest
+func (tr *TorchRequest) AddFeedComplex64(index int, shape []int64, content []complex64) {
+	tr.addFeed(index, &torch_predict_protos.ArrayProto{
+		Dtype:      TorchType_DT_COMPLEX64,
+		ArrayShape: &torch_predict_protos.ArrayShape{Dim: shape},
+		FloatVal:   packComplex64(content),
+	})
+}
+
+// AddFeedComplex128 function adds double-precision complex values input data for torchrequest
+func (tr *TorchRequest) AddFeedComplex128(index int, shape []int64, content []complex128) {
+	tr.addFeed(index, &torch_predict_protos.ArrayProto{
+		Dtype:      TorchType_DT_COMPLEX128,
+		ArrayShape: &torch_predict_protos.ArrayShape{Dim: shape},
+		DoubleVal:  packComplex128(content),
+	})
+}
+
+// AddFeedQInt8 function adds quantized int8 values input data for torchrequest
+func (tr *TorchRequest) AddFeedQInt8(index int, shape []int64, content []int8) {
+	tr.addFeed(index, &torch_predict_protos.ArrayProto{
+		Dtype:      TorchType_DT_QINT8,
+		ArrayShape: &torch_predict_protos.ArrayShape{Dim: shape},
+		IntVal:     packInt8(content),
+	})
+}
+
+// AddFeedQUint8 function adds quantized uint8 values input data for torchrequest
+func (tr *TorchRequest) AddFeedQUint8(index int, shape []int64, content []uint8) {
+	tr.addFeed(index, &torch_predict_protos.ArrayProto{
+		Dtype:      TorchType_DT_QUINT8,
+		ArrayShape: &torch_predict_protos.ArrayShape{Dim: shape},
+		IntVal:     packUint8(content),
+	})
+}
+
+// AddFeedQInt32 function adds quantized int32 values input data for torchrequest
+func (tr *TorchRequest) AddFeedQInt32(index int, shape []int64, content []int32) {
+	tr.addFeed(index, &torch_predict_protos.ArrayProto{
+		Dtype:      TorchType_DT_QINT32,
+		ArrayShape: &torch_predict_protos.ArrayShape{Dim: shape},
+		IntVal:     content,
+	})
+}
+
+func (tr *TorchRequest) addFeed(index int, requestProto *torch_predict_protos.ArrayProto) {
+	for len(tr.RequestData.Inputs) < index+1 {
+		tr.RequestData.Inputs = append(tr.RequestData.Inputs, &torch_predict_protos.ArrayProto{})
+	}
+	tr.RequestData.Inputs[index] = requestProto
+}
+
 // AddFetch add OutputFilter (outIndex) for response
 func (tr *TorchRequest) AddFetch(outIndex int32) {
 	tr.RequestData.OutputFilter = append(tr.RequestData.OutputFilter, outIndex)
@@ -77,13 +200,18 @@ func (tr *TorchRequest) AddFetch(outIndex int32) {
 
 // ToString for interface
 func (tr TorchRequest) ToString() (string, error) {
-	reqData, err := proto.Marshal(&tr.RequestData)
+	reqData, err := tr.ToBytes()
 	if err != nil {
-		return "", NewPredictError(-1, "", err.Error())
+		return "", err
 	}
 	return string(reqData), nil
 }
 
+// ToBytes serializes the request into protobuf bytes without the string conversion
+func (tr TorchRequest) ToBytes() ([]byte, error) {
+	return tr.appendProto(make([]byte, 0, tr.protoSize())), nil
+}
+
 // TorchResponse class for PyTorch predicted results
 type TorchResponse struct {
 	Response torch_predict_protos.PredictResponse
@@ -91,36 +219,90 @@ type TorchResponse struct {
 
 // GetTensorShape returns []int64 slice as shape of tensor outindexed
 func (resp *TorchResponse) GetTensorShape(outIndex int) []int64 {
-	return resp.Response.Outputs[outIndex].ArrayShape.Dim
+	return resp.output(outIndex).GetArrayShape().GetDim()
 }
 
 // GetFloatVal returns []float32 slice as output data
 func (resp *TorchResponse) GetFloatVal(outIndex int) []float32 {
-	return resp.Response.Outputs[outIndex].GetFloatVal()
+	return resp.output(outIndex).GetFloatVal()
 }
 
 // GetDoubleVal returns []float64 slice as output data
 func (resp *TorchResponse) GetDoubleVal(outIndex int) []float64 {
-	return resp.Response.Outputs[outIndex].GetDoubleVal()
+	return resp.output(outIndex).GetDoubleVal()
 }
 
 // GetIntVal returns []int32 slice as output data
 func (resp *TorchResponse) GetIntVal(outIndex int) []int32 {
-	return resp.Response.Outputs[outIndex].GetIntVal()
+	return resp.output(outIndex).GetIntVal()
 }
 
 // GetInt64Val returns []int64 slice as output data
 func (resp *TorchResponse) GetInt64Val(outIndex int) []int64 {
-	return resp.Response.Outputs[outIndex].GetInt64Val()
+	return resp.output(outIndex).GetInt64Val()
+}
+
+// GetBoolVal returns []bool slice as output data of DT_BOOL
+func (resp *TorchResponse) GetBoolVal(outIndex int) []bool {
+	output := resp.output(outIndex)
+	return unpackBool(output.GetIntVal())
+}
+
+// GetStringVal returns [][]byte slice as output data of DT_STRING
+func (resp *TorchResponse) GetStringVal(outIndex int) [][]byte {
+	output := resp.output(outIndex)
+	return output.GetStringVal()
+}
+
+// GetUint8Val returns []uint8 slice as output data of DT_UINT8 or DT_QUINT8
+func (resp *TorchResponse) GetUint8Val(outIndex int) []uint8 {
+	output := resp.output(outIndex)
+	return unpackUint8(output.GetIntVal())
+}
+
+// GetInt8Val returns []int8 slice as output data of DT_INT8 or DT_QINT8
+func (resp *TorchResponse) GetInt8Val(outIndex int) []int8 {
+	output := resp.output(outIndex)
+	return unpackInt8(output.GetIntVal())
+}
+
+// GetInt16Val returns []int16 slice as output data of DT_INT16
+func (resp *TorchResponse) GetInt16Val(outIndex int) []int16 {
+	output := resp.output(outIndex)
+	return unpackInt16(output.GetIntVal())
+}
+
+// GetUint16Val returns []uint16 slice as output data of DT_UINT16
+func (resp *TorchResponse) GetUint16Val(outIndex int) []uint16 {
+	output := resp.output(outIndex)
+	return unpackUint16(output.GetIntVal())
+}
+
+// GetHalfVal returns []float32 slice as output data of DT_HALF converted to float32
+func (resp *TorchResponse) GetHalfVal(outIndex int) []float32 {
+	output := resp.output(outIndex)
+	return unpackHalf(output.GetIntVal())
+}
+
+// GetBFloat16Val returns []float32 slice as output data of DT_BFLOAT16 converted to float32
+func (resp *TorchResponse) GetBFloat16Val(outIndex int) []float32 {
+	output := resp.output(outIndex)
+	return unpackBFloat16(output.GetIntVal())
+}
+
+// GetComplex64Val returns []complex64 slice as output data of DT_COMPLEX64
+func (resp *TorchResponse) GetComplex64Val(outIndex int) []complex64 {
+	output := resp.output(outIndex)
+	return unpackComplex64(output.GetFloatVal())
+}
+
+// GetComplex128Val returns []complex128 slice as output data of DT_COMPLEX128
+func (resp *TorchResponse) GetComplex128Val(outIndex int) []complex128 {
+	output := resp.output(outIndex)
+	return unpackComplex128(output.GetDoubleVal())
 }
 
 // Unmarshal for interface
 func (resp *TorchResponse) unmarshal(body []byte) error {
-	bd := &torch_predict_protos.PredictResponse{}
-	err := proto.Unmarshal(body, bd)
-	if err != nil {
-		return err
-	}
-	resp.Response = *bd
-	return nil
+	return decodeTorchResponse(body, &resp.Response)
 }
@@ -0,0 +1,241 @@
+package eas
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/pai-eas/eas-golang-sdk/eas/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// SchemaTag is the tag of the schema of messages put by TypedQueue.
+const SchemaTag = "msg.schema"
+
+// MessageCodec encodes and decodes the messages of TypedQueue, implementations must be safe for
+// concurrent use.
+type MessageCodec interface {
+	// Schema identifies the schema and version of messages, e.g. "example.Order/v2", which is checked
+	// on read so mismatched producers and consumers fail.
+	Schema() string
+	// New returns a new message to decode into.
+	New() interface{}
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// SchemaMismatchError is the error receiving a frame whose schema is not the one of codec.
+type SchemaMismatchError struct {
+	Index    uint64
+	Schema   string
+	Expected string
+}
+
+func (e *SchemaMismatchError) Error() string {
+	if len(e.Schema) == 0 {
+		return fmt.Sprintf("frame %d has no %s tag, expected schema %q", e.Index, SchemaTag, e.Expected)
+	}
+	return fmt.Sprintf("frame %d has schema %q, expected %q", e.Index, e.Schema, e.Expected)
+}
+
+type jsonMessageCodec struct {
+	schema string
+	typ    reflect.Type
+}
+
+// NewJSONCodec creates the codec of messages in JSON, which are decoded into new values of the type of
+// prototype, e.g. Order{} or &Order{} both decode messages into *Order.
+func NewJSONCodec(schema string, prototype interface{}) MessageCodec {
+	typ := reflect.TypeOf(prototype)
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return &jsonMessageCodec{schema: schema, typ: typ}
+}
+
+func (c *jsonMessageCodec) Schema() string {
+	return c.schema
+}
+
+func (c *jsonMessageCodec) New() interface{} {
+	return reflect.New(c.typ).Interface()
+}
+
+func (c *jsonMessageCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (c *jsonMessageCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+type protoMessageCodec struct {
+	schema    string
+	prototype proto.Message
+}
+
+// NewProtoCodec creates the codec of protobuf messages, which are decoded into new messages of the
+// type of prototype.
+func NewProtoCodec(schema string, prototype proto.Message) MessageCodec {
+	return &protoMessageCodec{schema: schema, prototype: prototype}
+}
+
+func (c *protoMessageCodec) Schema() string {
+	return c.schema
+}
+
+func (c *protoMessageCodec) New() interface{} {
+	return c.prototype.ProtoReflect().New().Interface()
+}
+
+func (c *protoMessageCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("%T is not a proto.Message", v)
+	}
+	return proto.Marshal(m)
+}
+
+func (c *protoMessageCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("%T is not a proto.Message", v)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+// TypedMessage is the message decoded from a frame of queue.
+type TypedMessage struct {
+	Index types.Index
+	Tags  types.Tags
+	// Value is the message returned by MessageCodec.New, nil if Err is set.
+	Value interface{}
+	// Err is the error receiving or decoding the frame returned by Get or delivered by TypedWatcher, e.g. a
+	// *SchemaMismatchError, the frame should be handled by its index, e.g. deleted.
+	Err error
+}
+
+// TypedQueue puts and receives the messages of a schema through QueueClient, the schema is recorded in
+// the msg.schema tag of frames. Frames are acknowledged through QueueClient by their indexes.
+type TypedQueue struct {
+	queue *QueueClient
+	codec MessageCodec
+}
+
+// NewTypedQueue creates the typed queue of messages encoded by codec.
+func NewTypedQueue(queue *QueueClient, codec MessageCodec) (*TypedQueue, error) {
+	if len(codec.Schema()) == 0 {
+		return nil, fmt.Errorf("schema of messages is required")
+	}
+	return &TypedQueue{queue: queue, codec: codec}, nil
+}
+
+// Put puts the message into queue. It returns the index of the message in queue, and generated request id.
+func (t *TypedQueue) Put(ctx context.Context, v interface{}, tags types.Tags) (uint64, string, error) {
+	return t.PutWithPriority(ctx, v, tags, 0)
+}
+
+// PutWithPriority puts the message into queue with priority.
+func (t *TypedQueue) PutWithPriority(ctx context.Context, v interface{}, tags types.Tags, prio types.Priority) (uint64, string, error) {
+	data, err := t.codec.Marshal(v)
+	if err != nil {
+		return 0, "", fmt.Errorf("encode message of schema %q: %v", t.codec.Schema(), err)
+	}
+	msgTags := types.Tags{}
+	for k, v := range tags {
+		msgTags[k] = v
+	}
+	msgTags[SchemaTag] = t.codec.Schema()
+	return t.queue.PutWithPriority(ctx, data, msgTags, prio)
+}
+
+// decode decodes the message of frame, the schema tag is removed from the tags of message.
+func (t *TypedQueue) decode(df types.DataFrame) TypedMessage {
+	msg := TypedMessage{Index: df.Index, Tags: types.Tags{}}
+	for k, v := range df.Tags {
+		if k != SchemaTag {
+			msg.Tags[k] = v
+		}
+	}
+	if len(df.Message) > 0 {
+		msg.Err = fmt.Errorf("frame %d: %s", df.Index.Uint64(), df.Message)
+		return msg
+	}
+	if schema := df.Tags.Get(SchemaTag); schema != t.codec.Schema() {
+		msg.Err = &SchemaMismatchError{Index: df.Index.Uint64(), Schema: schema, Expected: t.codec.Schema()}
+		return msg
+	}
+	v := t.codec.New()
+	if err := t.codec.Unmarshal(df.Data, v); err != nil {
+		msg.Err = fmt.Errorf("decode frame %d of schema %q: %v", df.Index.Uint64(), t.codec.Schema(), err)
+		return msg
+	}
+	msg.Value = v
+	return msg
+}
+
+// Get gets the messages like QueueClient.Get, the frames failing to be received or decoded are returned
+// with Err like Watch, along with the error of the first one.
+func (t *TypedQueue) Get(ctx context.Context, index uint64, length int, timeout time.Duration, autoDelete bool, tags types.Tags) ([]TypedMessage, error) {
+	frames, err := t.queue.Get(ctx, index, length, timeout, autoDelete, tags)
+	var receiveErr *ReceiveError
+	if err != nil && !errors.As(err, &receiveErr) {
+		return nil, err
+	}
+	msgs := make([]TypedMessage, 0, len(frames))
+	for _, df := range frames {
+		msg := t.decode(df)
+		if msg.Err != nil && err == nil {
+			err = msg.Err
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, err
+}
+
+// Watch watches the messages like QueueClient.Watch, the frames failing to be received or decoded are
+// delivered with Err.
+func (t *TypedQueue) Watch(ctx context.Context, index, window uint64, autocommit bool) (*TypedWatcher, error) {
+	watcher, err := t.queue.Watch(ctx, index, window, false, autocommit)
+	if err != nil {
+		return nil, err
+	}
+	w := &TypedWatcher{watcher: watcher, queue: t, ch: make(chan TypedMessage), done: make(chan struct{})}
+	go w.run()
+	return w, nil
+}
+
+// TypedWatcher delivers the messages watched by TypedQueue.
+type TypedWatcher struct {
+	watcher types.Watcher
+	queue   *TypedQueue
+	ch      chan TypedMessage
+	done    chan struct{}
+	once    sync.Once
+}
+
+// MessageChan returns the channel of messages, which is closed once the watcher is closed or broken.
+func (w *TypedWatcher) MessageChan() <-chan TypedMessage {
+	return w.ch
+}
+
+// Close stops the watcher and closes the MessageChan.
+func (w *TypedWatcher) Close() {
+	w.once.Do(func() { close(w.done) })
+	w.watcher.Close()
+}
+
+func (w *TypedWatcher) run() {
+	defer close(w.ch)
+	for df := range w.watcher.FrameChan() {
+		select {
+		case w.ch <- w.queue.decode(df):
+		case <-w.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package eas
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pai-eas/eas-golang-sdk/eas/internal/queuetest"
+	"github.com/pai-eas/eas-golang-sdk/eas/types"
+	"github.com/pai-eas/eas-golang-sdk/eas/types/queue_service_protos"
+)
+
+type order struct {
+	Id    string  `json:"id"`
+	Price float64 `json:"price"`
+}
+
+func TestTypedQueue(t *testing.T) {
+	server := queuetest.NewServer()
+	defer server.Close()
+	client := newTestQueueClient(t, server)
+	orders, err := NewTypedQueue(client, NewJSONCodec("order/v1", order{}))
+	assertNoError(t, err)
+	ctx := context.Background()
+
+	index, _, err := orders.Put(ctx, order{Id: "o-1", Price: 9.5}, types.Tags{"k": "v"})
+	assertNoError(t, err)
+	assertEqual(t, server.Frames()[0].Tags[SchemaTag], "order/v1")
+	msgs, err := orders.Get(ctx, index, 1, 0, false, types.Tags{})
+	assertNoError(t, err)
+	assertEqual(t, *msgs[0].Value.(*order), order{Id: "o-1", Price: 9.5})
+	assertEqual(t, msgs[0].Index.Uint64(), index)
+	assertEqual(t, msgs[0].Tags.String(), "tags[k=v requestId=req-1]")
+
+	// the messages of other schemas fail loudly
+	ordersV2, err := NewTypedQueue(client, NewJSONCodec("order/v2", &order{}))
+	assertNoError(t, err)
+	msgs, err = ordersV2.Get(ctx, index, 1, 0, false, types.Tags{})
+	var mismatch *SchemaMismatchError
+	if !errors.As(err, &mismatch) || mismatch.Schema != "order/v1" || mismatch.Expected != "order/v2" {
+		t.Fatalf("expect schema mismatch, got %v", err)
+	}
+	assertEqual(t, len(msgs), 1)
+	assertEqual(t, msgs[0].Err, err)
+
+	// the messages decoded are returned along with the failed ones
+	_, _, err = client.Put(ctx, []byte("raw"), nil)
+	assertNoError(t, err)
+	msgs, err = orders.Get(ctx, index, 2, 0, false, types.Tags{})
+	if !errors.As(err, &mismatch) || mismatch.Schema != "" {
+		t.Fatalf("expect missing schema, got %v", err)
+	}
+	assertEqual(t, len(msgs), 2)
+	assertNoError(t, msgs[0].Err)
+	assertEqual(t, msgs[0].Value.(*order).Id, "o-1")
+	if msgs[1].Err == nil || msgs[1].Value != nil {
+		t.Fatalf("expect decoding error, got %+v", msgs[1])
+	}
+
+	watcher, err := orders.Watch(ctx, 0, 10, false)
+	assertNoError(t, err)
+	defer watcher.Close()
+	msg := <-watcher.MessageChan()
+	assertNoError(t, msg.Err)
+	assertEqual(t, msg.Value.(*order).Id, "o-1")
+	msg = <-watcher.MessageChan()
+	if !errors.As(msg.Err, &mismatch) || mismatch.Schema != "" || msg.Value != nil {
+		t.Fatalf("expect missing schema, got %+v", msg)
+	}
+	server.Put([]byte("{"), types.Tags{SchemaTag: "order/v1"})
+	msg = <-watcher.MessageChan()
+	if msg.Err == nil {
+		t.Fatalf("expect decoding error, got %+v", msg)
+	}
+}
+
+func TestTypedQueueProto(t *testing.T) {
+	server := queuetest.NewServer()
+	defer server.Close()
+	attrs, err := NewTypedQueue(newTestQueueClient(t, server), NewProtoCodec("attributes/v1", &queue_service_protos.AttributesProto{}))
+	assertNoError(t, err)
+	ctx := context.Background()
+
+	index, _, err := attrs.Put(ctx, &queue_service_protos.AttributesProto{Attributes: map[string]string{"a": "b"}}, nil)
+	assertNoError(t, err)
+	msgs, err := attrs.Get(ctx, index, 1, 0, false, types.Tags{})
+	assertNoError(t, err)
+	assertEqual(t, msgs[0].Value.(*queue_service_protos.AttributesProto).Attributes["a"], "b")
+	if _, _, err = attrs.Put(ctx, "not a message", nil); err == nil {
+		t.Fatalf("expect encoding error")
+	}
+}
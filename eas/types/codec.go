@@ -5,9 +5,13 @@ import (
 	"encoding/base64"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/pai-eas/eas-golang-sdk/eas/types/queue_service_protos"
 	"google.golang.org/protobuf/proto"
 	"io"
+	"sort"
+	"sync"
 )
 
 type (
@@ -22,40 +26,116 @@ type (
 )
 
 const (
-	ContentTypeProtobuf   = "application/vnd.google.protobuf"
+	ContentTypeProtobuf = "application/vnd.google.protobuf"
+	// ContentTypeFlatbuffer has no built-in codec, since the FlatBuffers schema of queue service is not
+	// published, DataFrameCodecFor returns ErrUnsupportedMediaType for it unless a codec is registered.
 	ContentTypeFlatbuffer = "application/x-flatbuffers"
 	ContentTypeJSON       = "application/json"
 )
 
-func DataFrameCodecFor(contentType string) DataFrameCodec {
-	switch contentType {
-	case ContentTypeProtobuf:
-		return &pbDataFrameCodec{}
-	case ContentTypeJSON:
-		return &jsonDataFrameCodec{}
-	default:
-		return nil
+// ErrUnsupportedMediaType is returned for the media types without codec.
+var ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+// DataFrameCodecFactory creates the DataFrameCodec of a media type.
+type DataFrameCodecFactory func() DataFrameCodec
+
+// AttributesCodecFactory creates the AttributesCodec of a media type.
+type AttributesCodecFactory func() AttributesCodec
+
+var (
+	codecsMu sync.RWMutex
+	// codecs of the media types, the built-in ones are registered at first.
+	dataFrameCodecs = map[string]DataFrameCodecFactory{
+		ContentTypeProtobuf: func() DataFrameCodec { return &pbDataFrameCodec{} },
+		ContentTypeJSON:     func() DataFrameCodec { return &jsonDataFrameCodec{} },
+	}
+	attributesCodecs = map[string]AttributesCodecFactory{
+		ContentTypeProtobuf: func() AttributesCodec { return &pbAttributesCodec{} },
+		ContentTypeJSON:     func() AttributesCodec { return &jsonAttributesCodec{} },
+	}
+)
+
+// RegisterDataFrameCodec makes the DataFrameCodec of mediaType available to DataFrameCodecFor, it is
+// usually called in the init function of the package implementing the codec. It panics if mediaType
+// is registered already or factory is nil.
+func RegisterDataFrameCodec(mediaType string, factory DataFrameCodecFactory) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	if factory == nil {
+		panic("types: nil data frame codec factory of " + mediaType)
+	}
+	if _, ok := dataFrameCodecs[mediaType]; ok {
+		panic("types: data frame codec of " + mediaType + " is registered twice")
+	}
+	dataFrameCodecs[mediaType] = factory
+}
+
+// RegisterAttributesCodec makes the AttributesCodec of mediaType available to AttributesCodecFor, it
+// panics if mediaType is registered already or factory is nil.
+func RegisterAttributesCodec(mediaType string, factory AttributesCodecFactory) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	if factory == nil {
+		panic("types: nil attributes codec factory of " + mediaType)
+	}
+	if _, ok := attributesCodecs[mediaType]; ok {
+		panic("types: attributes codec of " + mediaType + " is registered twice")
+	}
+	attributesCodecs[mediaType] = factory
+}
+
+// DataFrameMediaTypes returns the sorted media types of registered DataFrameCodecs.
+func DataFrameMediaTypes() []string {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	mediaTypes := make([]string, 0, len(dataFrameCodecs))
+	for mediaType := range dataFrameCodecs {
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	sort.Strings(mediaTypes)
+	return mediaTypes
+}
+
+// AttributesMediaTypes returns the sorted media types of registered AttributesCodecs.
+func AttributesMediaTypes() []string {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	mediaTypes := make([]string, 0, len(attributesCodecs))
+	for mediaType := range attributesCodecs {
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	sort.Strings(mediaTypes)
+	return mediaTypes
+}
+
+func DataFrameCodecFor(contentType string) (DataFrameCodec, error) {
+	codecsMu.RLock()
+	factory, ok := dataFrameCodecs[contentType]
+	codecsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("data frame codec of %q: %w", contentType, ErrUnsupportedMediaType)
 	}
+	return factory(), nil
 }
 
-func AttributesCodecFor(contentType string) AttributesCodec {
-	switch contentType {
-	case ContentTypeProtobuf:
-		return &pbAttributesCodec{}
-	case ContentTypeJSON:
-		return &jsonAttributesCodec{}
-	default:
-		return nil
+func AttributesCodecFor(contentType string) (AttributesCodec, error) {
+	codecsMu.RLock()
+	factory, ok := attributesCodecs[contentType]
+	codecsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("attributes codec of %q: %w", contentType, ErrUnsupportedMediaType)
 	}
+	return factory(), nil
 }
 
 func (p *pbDataFrameCodec) EncodeList(list []DataFrame, w io.Writer) error {
 	dfProto := queue_service_protos.DataFrameListProto{}
 	for _, df := range list {
 		dfProto.Index = append(dfProto.Index, &queue_service_protos.DataFrameProto{
-			Index: df.Index.Uint64(),
-			Data:  df.Data,
-			Tags:  df.Tags,
+			Index:   df.Index.Uint64(),
+			Data:    df.Data,
+			Tags:    df.Tags,
+			Message: df.Message,
 		})
 	}
 	data, err := proto.Marshal(&dfProto)
@@ -74,9 +154,10 @@ func (p *pbDataFrameCodec) DecodeList(bytes []byte) ([]DataFrame, error) {
 	ret := make([]DataFrame, 0, len(dfProto.Index))
 	for _, idx := range dfProto.Index {
 		ret = append(ret, DataFrame{
-			Data:  idx.Data,
-			Index: FromUint64(idx.Index),
-			Tags:  idx.Tags,
+			Data:    idx.Data,
+			Index:   FromUint64(idx.Index),
+			Tags:    idx.Tags,
+			Message: idx.Message,
 		})
 	}
 	return ret, nil
@@ -104,6 +185,7 @@ func (p *pbDataFrameCodec) Decode(bytes []byte, frame *DataFrame) error {
 	frame.Tags = dfProto.Tags
 	frame.Index = FromUint64(dfProto.Index)
 	frame.Data = dfProto.Data
+	frame.Message = dfProto.Message
 	return nil
 }
 
@@ -0,0 +1,182 @@
+// Package codectest provides the round-trip conformance tests of DataFrameCodec and AttributesCodec
+// implementations, which codecs registered by types.RegisterDataFrameCodec should pass, e.g.
+//
+//	func TestCodec(t *testing.T) {
+//		codectest.TestDataFrameCodec(t, &myCodec{})
+//		codectest.TestAttributesCodec(t, &myAttributesCodec{})
+//	}
+package codectest
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/pai-eas/eas-golang-sdk/eas/types"
+)
+
+// frames covers the edge cases of DataFrame fields.
+func frames() map[string]types.DataFrame {
+	binary := make([]byte, 256)
+	for i := range binary {
+		binary[i] = byte(i)
+	}
+	return map[string]types.DataFrame{
+		"empty": {},
+		"full": {
+			Index:   types.FromUint64(10000),
+			Tags:    types.Tags{"foo": "bar", "requestId": "req-1"},
+			Data:    []byte("hello world"),
+			Message: "12345",
+		},
+		"binary":  {Index: types.FromUint64(1), Data: binary},
+		"unicode": {Index: types.FromUint64(2), Tags: types.Tags{"名字": "值 with spaces", "empty": ""}, Message: "消息"},
+		"max":     {Index: types.FromUint64(^uint64(0)), Data: []byte{0}},
+		"large":   {Index: types.FromUint64(3), Data: bytes.Repeat([]byte("0123456789"), 100000)},
+	}
+}
+
+// normalize makes the empty fields nil, codecs are free to decode them as nil or empty.
+func normalize(frame types.DataFrame) types.DataFrame {
+	if len(frame.Tags) == 0 {
+		frame.Tags = nil
+	}
+	if len(frame.Data) == 0 {
+		frame.Data = nil
+	}
+	return frame
+}
+
+func assertFrame(t *testing.T, got, want types.DataFrame) {
+	t.Helper()
+	got, want = normalize(got), normalize(want)
+	if got.Index != want.Index || got.Message != want.Message || !bytes.Equal(got.Data, want.Data) ||
+		!reflect.DeepEqual(got.Tags, want.Tags) {
+		t.Fatalf("frame is not round-tripped, got index %d, tags %v, message %q, %d bytes of data, "+
+			"want index %d, tags %v, message %q, %d bytes of data", got.Index, got.Tags, got.Message, len(got.Data),
+			want.Index, want.Tags, want.Message, len(want.Data))
+	}
+}
+
+// TestDataFrameCodec checks codec round-trips frames and lists of frames by Encode, Decode,
+// EncodeList and DecodeList.
+func TestDataFrameCodec(t *testing.T, codec types.DataFrameCodec) {
+	t.Run("MediaType", func(t *testing.T) {
+		if len(codec.MediaType()) == 0 {
+			t.Fatal("empty media type")
+		}
+	})
+	for name, frame := range frames() {
+		frame := frame
+		t.Run("Frame/"+name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if err := codec.Encode(frame, buf); err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			// decoding into a used frame overwrites all its fields
+			got := types.DataFrame{Index: 7, Tags: types.Tags{"stale": "tag"}, Data: []byte("stale"), Message: "stale"}
+			if err := codec.Decode(buf.Bytes(), &got); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			assertFrame(t, got, frame)
+		})
+	}
+	t.Run("List", func(t *testing.T) {
+		var list []types.DataFrame
+		for i := 0; i < 10; i++ {
+			list = append(list, types.DataFrame{
+				Index:   types.FromUint64(uint64(100 - i)),
+				Tags:    types.Tags{"i": fmt.Sprint(i)},
+				Data:    []byte(fmt.Sprintf("data %d", i)),
+				Message: fmt.Sprintf("message %d", i),
+			})
+		}
+		list = append(list, types.DataFrame{})
+		buf := &bytes.Buffer{}
+		if err := codec.EncodeList(list, buf); err != nil {
+			t.Fatalf("encode list: %v", err)
+		}
+		got, err := codec.DecodeList(buf.Bytes())
+		if err != nil {
+			t.Fatalf("decode list: %v", err)
+		}
+		if len(got) != len(list) {
+			t.Fatalf("got %d frames, want %d", len(got), len(list))
+		}
+		// the order of frames is kept
+		for i := range list {
+			assertFrame(t, got[i], list[i])
+		}
+	})
+	t.Run("EmptyList", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		if err := codec.EncodeList(nil, buf); err != nil {
+			t.Fatalf("encode list: %v", err)
+		}
+		got, err := codec.DecodeList(buf.Bytes())
+		if err != nil {
+			t.Fatalf("decode list: %v", err)
+		}
+		if len(got) != 0 {
+			t.Fatalf("got %d frames, want none", len(got))
+		}
+	})
+	t.Run("Concurrent", func(t *testing.T) {
+		frame := frames()["full"]
+		errs := make(chan error, 8)
+		for i := 0; i < cap(errs); i++ {
+			go func() {
+				buf := &bytes.Buffer{}
+				var got types.DataFrame
+				err := codec.Encode(frame, buf)
+				if err == nil {
+					err = codec.Decode(buf.Bytes(), &got)
+				}
+				if err == nil && !bytes.Equal(got.Data, frame.Data) {
+					err = fmt.Errorf("got data %q, want %q", got.Data, frame.Data)
+				}
+				errs <- err
+			}()
+		}
+		for i := 0; i < cap(errs); i++ {
+			if err := <-errs; err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+}
+
+// TestAttributesCodec checks codec round-trips attributes by Encode and Decode.
+func TestAttributesCodec(t *testing.T, codec types.AttributesCodec) {
+	t.Run("MediaType", func(t *testing.T) {
+		if len(codec.MediaType()) == 0 {
+			t.Fatal("empty media type")
+		}
+	})
+	cases := map[string]types.Attributes{
+		"empty": {},
+		"full": {
+			types.Name:               "queue",
+			types.MaxPayloadBytes:    "8388608",
+			types.UserIdentifyHeader: "X-Eas-Queueservice-User",
+		},
+		"unicode": {"名字": "值", "empty": ""},
+	}
+	for name, attr := range cases {
+		attr := attr
+		t.Run(name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if err := codec.Encode(attr, buf); err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			got := types.Attributes{}
+			if err := codec.Decode(buf.Bytes(), &got); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if len(got) != len(attr) || (len(attr) > 0 && !reflect.DeepEqual(got, attr)) {
+				t.Fatalf("got %v, want %v", got, attr)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package codectest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pai-eas/eas-golang-sdk/eas/types"
+)
+
+func TestRegisteredCodecs(t *testing.T) {
+	for _, mediaType := range types.DataFrameMediaTypes() {
+		codec, err := types.DataFrameCodecFor(mediaType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Run(mediaType, func(t *testing.T) {
+			TestDataFrameCodec(t, codec)
+		})
+	}
+	for _, mediaType := range types.AttributesMediaTypes() {
+		codec, err := types.AttributesCodecFor(mediaType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Run(mediaType, func(t *testing.T) {
+			TestAttributesCodec(t, codec)
+		})
+	}
+}
+
+func TestFlatbufferUnsupported(t *testing.T) {
+	if _, err := types.DataFrameCodecFor(types.ContentTypeFlatbuffer); !errors.Is(err, types.ErrUnsupportedMediaType) {
+		t.Fatalf("expect ErrUnsupportedMediaType, got %v", err)
+	}
+	if _, err := types.AttributesCodecFor(types.ContentTypeFlatbuffer); !errors.Is(err, types.ErrUnsupportedMediaType) {
+		t.Fatalf("expect ErrUnsupportedMediaType, got %v", err)
+	}
+}
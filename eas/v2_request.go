@@ -0,0 +1,711 @@
+package eas
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Data types of tensors in the open inference protocol v2, served by Triton and KServe.
+const (
+	V2TypeBool   = "BOOL"
+	V2TypeUint8  = "UINT8"
+	V2TypeUint16 = "UINT16"
+	V2TypeUint32 = "UINT32"
+	V2TypeUint64 = "UINT64"
+	V2TypeInt8   = "INT8"
+	V2TypeInt16  = "INT16"
+	V2TypeInt32  = "INT32"
+	V2TypeInt64  = "INT64"
+	V2TypeFP16   = "FP16"
+	V2TypeFP32   = "FP32"
+	V2TypeFP64   = "FP64"
+	V2TypeBytes  = "BYTES"
+)
+
+// headerInferenceHeaderLength is the length of JSON header in a request or a response
+// using the binary tensor data extension.
+const headerInferenceHeaderLength = "Inference-Header-Content-Length"
+
+// V2Tensor is an input or output tensor of the inference protocol v2. Data holds the content
+// flattened in row-major order, as a typed slice like []float32, or [][]byte for BYTES tensors.
+type V2Tensor struct {
+	Name       string                 `json:"name"`
+	Shape      []int64                `json:"shape"`
+	Datatype   string                 `json:"datatype"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	Data       interface{}            `json:"data,omitempty"`
+}
+
+// V2RequestOutput specifies an output requested from the model.
+type V2RequestOutput struct {
+	Name       string                 `json:"name"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// V2Request is an inference request of the open inference protocol v2.
+type V2Request struct {
+	ID         string                 `json:"id,omitempty"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	Inputs     []*V2Tensor            `json:"inputs"`
+	Outputs    []V2RequestOutput      `json:"outputs,omitempty"`
+
+	modelVersion string
+	binary       bool
+}
+
+// SetModelVersion sets the version of model to infer, the server chooses it if not set.
+func (r *V2Request) SetModelVersion(version string) {
+	r.modelVersion = version
+}
+
+// SetBinaryData enables the binary tensor data extension, the inputs are sent and the outputs are
+// requested in raw little-endian bytes instead of JSON arrays, which is much more compact.
+func (r *V2Request) SetBinaryData(binary bool) {
+	r.binary = binary
+}
+
+// AddFeed adds an input tensor with the content given as a typed slice matching the datatype.
+func (r *V2Request) AddFeed(inputName string, datatype string, shape []int64, content interface{}) {
+	for i, input := range r.Inputs {
+		if input.Name == inputName {
+			r.Inputs = append(r.Inputs[:i], r.Inputs[i+1:]...)
+			break
+		}
+	}
+	r.Inputs = append(r.Inputs, &V2Tensor{
+		Name:     inputName,
+		Shape:    shape,
+		Datatype: datatype,
+		Data:     content,
+	})
+}
+
+// AddFeedFloat16 adds an input of FP16 tensor, the content is converted from float32
+func (r *V2Request) AddFeedFloat16(inputName string, shape []int64, content []float32) {
+	r.AddFeed(inputName, V2TypeFP16, shape, content)
+}
+
+// AddFeedFloat32 adds an input of FP32 tensor
+func (r *V2Request) AddFeedFloat32(inputName string, shape []int64, content []float32) {
+	r.AddFeed(inputName, V2TypeFP32, shape, content)
+}
+
+// AddFeedFloat64 adds an input of FP64 tensor
+func (r *V2Request) AddFeedFloat64(inputName string, shape []int64, content []float64) {
+	r.AddFeed(inputName, V2TypeFP64, shape, content)
+}
+
+// AddFeedInt8 adds an input of INT8 tensor
+func (r *V2Request) AddFeedInt8(inputName string, shape []int64, content []int8) {
+	r.AddFeed(inputName, V2TypeInt8, shape, content)
+}
+
+// AddFeedInt16 adds an input of INT16 tensor
+func (r *V2Request) AddFeedInt16(inputName string, shape []int64, content []int16) {
+	r.AddFeed(inputName, V2TypeInt16, shape, content)
+}
+
+// AddFeedInt32 adds an input of INT32 tensor
+func (r *V2Request) AddFeedInt32(inputName string, shape []int64, content []int32) {
+	r.AddFeed(inputName, V2TypeInt32, shape, content)
+}
+
+// AddFeedInt64 adds an input of INT64 tensor
+func (r *V2Request) AddFeedInt64(inputName string, shape []int64, content []int64) {
+	r.AddFeed(inputName, V2TypeInt64, shape, content)
+}
+
+// AddFeedUint8 adds an input of UINT8 tensor
+func (r *V2Request) AddFeedUint8(inputName string, shape []int64, content []uint8) {
+	r.AddFeed(inputName, V2TypeUint8, shape, content)
+}
+
+// AddFeedUint16 adds an input of UINT16 tensor
+func (r *V2Request) AddFeedUint16(inputName string, shape []int64, content []uint16) {
+	r.AddFeed(inputName, V2TypeUint16, shape, content)
+}
+
+// AddFeedUint32 adds an input of UINT32 tensor
+func (r *V2Request) AddFeedUint32(inputName string, shape []int64, content []uint32) {
+	r.AddFeed(inputName, V2TypeUint32, shape, content)
+}
+
+// AddFeedUint64 adds an input of UINT64 tensor
+func (r *V2Request) AddFeedUint64(inputName string, shape []int64, content []uint64) {
+	r.AddFeed(inputName, V2TypeUint64, shape, content)
+}
+
+// AddFeedBool adds an input of BOOL tensor
+func (r *V2Request) AddFeedBool(inputName string, shape []int64, content []bool) {
+	r.AddFeed(inputName, V2TypeBool, shape, content)
+}
+
+// AddFeedString adds an input of BYTES tensor
+func (r *V2Request) AddFeedString(inputName string, shape []int64, content [][]byte) {
+	r.AddFeed(inputName, V2TypeBytes, shape, content)
+}
+
+// AddFetch adds an output to be returned, all outputs are returned if none is specified
+func (r *V2Request) AddFetch(outputName string) {
+	r.Outputs = append(r.Outputs, V2RequestOutput{Name: outputName})
+}
+
+// encode serializes the request, it returns the body and the length of JSON header,
+// or -1 if the binary data extension is not used.
+func (r *V2Request) encode() ([]byte, int, error) {
+	shadow := *r
+	shadow.Inputs = make([]*V2Tensor, 0, len(r.Inputs))
+	var raws [][]byte
+	for _, input := range r.Inputs {
+		tensor := *input
+		if r.binary {
+			raw, err := encodeV2Binary(input.Datatype, input.Data)
+			if err != nil {
+				return nil, 0, fmt.Errorf("input %s: %v", input.Name, err)
+			}
+			tensor.Parameters = copyParameters(input.Parameters)
+			tensor.Parameters["binary_data_size"] = len(raw)
+			tensor.Data = nil
+			raws = append(raws, raw)
+		} else {
+			data, err := encodeV2JSON(input.Data)
+			if err != nil {
+				return nil, 0, fmt.Errorf("input %s: %v", input.Name, err)
+			}
+			tensor.Data = data
+		}
+		shadow.Inputs = append(shadow.Inputs, &tensor)
+	}
+	if r.binary {
+		if len(r.Outputs) == 0 {
+			shadow.Parameters = copyParameters(r.Parameters)
+			shadow.Parameters["binary_data_output"] = true
+		} else {
+			shadow.Outputs = make([]V2RequestOutput, 0, len(r.Outputs))
+			for _, output := range r.Outputs {
+				output.Parameters = copyParameters(output.Parameters)
+				output.Parameters["binary_data"] = true
+				shadow.Outputs = append(shadow.Outputs, output)
+			}
+		}
+	}
+	header, err := json.Marshal(&shadow)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !r.binary {
+		return header, -1, nil
+	}
+	body := bytes.NewBuffer(header)
+	for _, raw := range raws {
+		body.Write(raw)
+	}
+	return body.Bytes(), len(header), nil
+}
+
+func copyParameters(parameters map[string]interface{}) map[string]interface{} {
+	ret := make(map[string]interface{}, len(parameters)+1)
+	for key, val := range parameters {
+		ret[key] = val
+	}
+	return ret
+}
+
+// encodeV2JSON converts the content into the form of JSON tensor data.
+func encodeV2JSON(data interface{}) (interface{}, error) {
+	switch content := data.(type) {
+	case [][]byte:
+		strs := make([]string, 0, len(content))
+		for _, b := range content {
+			strs = append(strs, string(b))
+		}
+		return strs, nil
+	case []uint8:
+		// avoid the base64 encoding of []byte in encoding/json
+		ints := make([]uint16, 0, len(content))
+		for _, v := range content {
+			ints = append(ints, uint16(v))
+		}
+		return ints, nil
+	case nil:
+		return nil, fmt.Errorf("no content")
+	}
+	return data, nil
+}
+
+// encodeV2Binary converts the content into raw little-endian bytes.
+func encodeV2Binary(datatype string, data interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	switch content := data.(type) {
+	case [][]byte:
+		var length [4]byte
+		for _, b := range content {
+			binary.LittleEndian.PutUint32(length[:], uint32(len(b)))
+			buf.Write(length[:])
+			buf.Write(b)
+		}
+		return buf.Bytes(), nil
+	case []float32:
+		if datatype == V2TypeFP16 {
+			halfs := make([]uint16, 0, len(content))
+			for _, f := range content {
+				halfs = append(halfs, float32ToHalf(f))
+			}
+			data = halfs
+		}
+	case []bool, []int8, []int16, []int32, []int64, []uint8, []uint16, []uint32, []uint64, []float64:
+	default:
+		return nil, fmt.Errorf("unsupported content type %T", data)
+	}
+	if err := binary.Write(buf, binary.LittleEndian, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// V2Response is an inference response of the open inference protocol v2.
+type V2Response struct {
+	ModelName    string                 `json:"model_name"`
+	ModelVersion string                 `json:"model_version,omitempty"`
+	ID           string                 `json:"id,omitempty"`
+	Parameters   map[string]interface{} `json:"parameters,omitempty"`
+	Outputs      []*V2Tensor            `json:"outputs"`
+}
+
+// decode parses the response body, headerLength is the length of JSON header if
+// the binary data extension is used, or -1 otherwise.
+func (resp *V2Response) decode(body []byte, headerLength int) error {
+	header := body
+	if headerLength >= 0 {
+		if headerLength > len(body) {
+			return fmt.Errorf("inference header length %d exceeds body size %d", headerLength, len(body))
+		}
+		header = body[:headerLength]
+	}
+	decoder := json.NewDecoder(bytes.NewReader(header))
+	decoder.UseNumber()
+	if err := decoder.Decode(resp); err != nil {
+		return err
+	}
+	raw := body[len(header):]
+	for _, output := range resp.Outputs {
+		if size, ok := output.Parameters["binary_data_size"]; ok {
+			n, err := strconv.Atoi(fmt.Sprint(size))
+			if err != nil || n < 0 || n > len(raw) {
+				return fmt.Errorf("output %s: invalid binary data size %v", output.Name, size)
+			}
+			data, err := decodeV2Binary(output.Datatype, raw[:n])
+			if err != nil {
+				return fmt.Errorf("output %s: %v", output.Name, err)
+			}
+			output.Data = data
+			raw = raw[n:]
+			continue
+		}
+		data, err := decodeV2JSON(output.Datatype, output.Data)
+		if err != nil {
+			return fmt.Errorf("output %s: %v", output.Name, err)
+		}
+		output.Data = data
+	}
+	return nil
+}
+
+// flattenV2JSON flattens the data of a JSON tensor, which may be nested by some servers.
+func flattenV2JSON(data interface{}, ret []interface{}) []interface{} {
+	if list, ok := data.([]interface{}); ok {
+		for _, item := range list {
+			ret = flattenV2JSON(item, ret)
+		}
+		return ret
+	}
+	return append(ret, data)
+}
+
+// decodeV2JSON converts the data of JSON tensor into a typed slice.
+func decodeV2JSON(datatype string, data interface{}) (interface{}, error) {
+	values := flattenV2JSON(data, nil)
+	if datatype == V2TypeBytes {
+		ret := make([][]byte, 0, len(values))
+		for _, v := range values {
+			s, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("unexpected BYTES element %v", v)
+			}
+			ret = append(ret, []byte(s))
+		}
+		return ret, nil
+	}
+	if datatype == V2TypeBool {
+		ret := make([]bool, 0, len(values))
+		for _, v := range values {
+			b, ok := v.(bool)
+			if !ok {
+				return nil, fmt.Errorf("unexpected BOOL element %v", v)
+			}
+			ret = append(ret, b)
+		}
+		return ret, nil
+	}
+	numbers := make([]json.Number, 0, len(values))
+	for _, v := range values {
+		n, ok := v.(json.Number)
+		if !ok {
+			return nil, fmt.Errorf("unexpected %s element %v", datatype, v)
+		}
+		numbers = append(numbers, n)
+	}
+	var err error
+	parseFloat := func(n json.Number) float64 {
+		f, e := n.Float64()
+		if e != nil && err == nil {
+			err = e
+		}
+		return f
+	}
+	parseInt := func(n json.Number) int64 {
+		i, e := strconv.ParseInt(n.String(), 10, 64)
+		if e != nil && err == nil {
+			err = e
+		}
+		return i
+	}
+	parseUint := func(n json.Number) uint64 {
+		u, e := strconv.ParseUint(n.String(), 10, 64)
+		if e != nil && err == nil {
+			err = e
+		}
+		return u
+	}
+	var ret interface{}
+	switch datatype {
+	case V2TypeFP16, V2TypeFP32:
+		s := make([]float32, len(numbers))
+		for i, n := range numbers {
+			s[i] = float32(parseFloat(n))
+		}
+		ret = s
+	case V2TypeFP64:
+		s := make([]float64, len(numbers))
+		for i, n := range numbers {
+			s[i] = parseFloat(n)
+		}
+		ret = s
+	case V2TypeInt8:
+		s := make([]int8, len(numbers))
+		for i, n := range numbers {
+			s[i] = int8(parseInt(n))
+		}
+		ret = s
+	case V2TypeInt16:
+		s := make([]int16, len(numbers))
+		for i, n := range numbers {
+			s[i] = int16(parseInt(n))
+		}
+		ret = s
+	case V2TypeInt32:
+		s := make([]int32, len(numbers))
+		for i, n := range numbers {
+			s[i] = int32(parseInt(n))
+		}
+		ret = s
+	case V2TypeInt64:
+		s := make([]int64, len(numbers))
+		for i, n := range numbers {
+			s[i] = parseInt(n)
+		}
+		ret = s
+	case V2TypeUint8:
+		s := make([]uint8, len(numbers))
+		for i, n := range numbers {
+			s[i] = uint8(parseUint(n))
+		}
+		ret = s
+	case V2TypeUint16:
+		s := make([]uint16, len(numbers))
+		for i, n := range numbers {
+			s[i] = uint16(parseUint(n))
+		}
+		ret = s
+	case V2TypeUint32:
+		s := make([]uint32, len(numbers))
+		for i, n := range numbers {
+			s[i] = uint32(parseUint(n))
+		}
+		ret = s
+	case V2TypeUint64:
+		s := make([]uint64, len(numbers))
+		for i, n := range numbers {
+			s[i] = parseUint(n)
+		}
+		ret = s
+	default:
+		return nil, fmt.Errorf("unsupported datatype %s", datatype)
+	}
+	return ret, err
+}
+
+// decodeV2Binary converts raw little-endian bytes into a typed slice.
+func decodeV2Binary(datatype string, raw []byte) (interface{}, error) {
+	var data interface{}
+	var size int
+	switch datatype {
+	case V2TypeBytes:
+		var ret [][]byte
+		for len(raw) > 0 {
+			if len(raw) < 4 {
+				return nil, fmt.Errorf("truncated BYTES element")
+			}
+			n := int(binary.LittleEndian.Uint32(raw))
+			if n > len(raw)-4 {
+				return nil, fmt.Errorf("truncated BYTES element")
+			}
+			ret = append(ret, raw[4:4+n])
+			raw = raw[4+n:]
+		}
+		return ret, nil
+	case V2TypeBool:
+		data, size = make([]bool, len(raw)), 1
+	case V2TypeUint8:
+		data, size = make([]uint8, len(raw)), 1
+	case V2TypeInt8:
+		data, size = make([]int8, len(raw)), 1
+	case V2TypeUint16, V2TypeFP16:
+		data, size = make([]uint16, len(raw)/2), 2
+	case V2TypeInt16:
+		data, size = make([]int16, len(raw)/2), 2
+	case V2TypeUint32:
+		data, size = make([]uint32, len(raw)/4), 4
+	case V2TypeInt32:
+		data, size = make([]int32, len(raw)/4), 4
+	case V2TypeFP32:
+		data, size = make([]float32, len(raw)/4), 4
+	case V2TypeUint64:
+		data, size = make([]uint64, len(raw)/8), 8
+	case V2TypeInt64:
+		data, size = make([]int64, len(raw)/8), 8
+	case V2TypeFP64:
+		data, size = make([]float64, len(raw)/8), 8
+	default:
+		return nil, fmt.Errorf("unsupported datatype %s", datatype)
+	}
+	if len(raw)%size != 0 {
+		return nil, fmt.Errorf("binary data size %d is not a multiple of %d", len(raw), size)
+	}
+	if err := binary.Read(bytes.NewReader(raw), binary.LittleEndian, data); err != nil {
+		return nil, err
+	}
+	if datatype == V2TypeFP16 {
+		halfs := data.([]uint16)
+		floats := make([]float32, len(halfs))
+		for i, h := range halfs {
+			floats[i] = halfToFloat32(h)
+		}
+		return floats, nil
+	}
+	return data, nil
+}
+
+// Output returns the output tensor named outputName.
+func (resp *V2Response) Output(outputName string) (*V2Tensor, bool) {
+	for _, output := range resp.Outputs {
+		if output.Name == outputName {
+			return output, true
+		}
+	}
+	return nil, false
+}
+
+// GetTensorShape returns []int64 slice as shape of the output tensor
+func (resp *V2Response) GetTensorShape(outputName string) []int64 {
+	if output, ok := resp.Output(outputName); ok {
+		return output.Shape
+	}
+	return nil
+}
+
+// GetFloatVal returns []float32 slice as output data of FP32 or FP16 tensor
+func (resp *V2Response) GetFloatVal(outputName string) []float32 {
+	v, _ := resp.data(outputName).([]float32)
+	return v
+}
+
+// GetDoubleVal returns []float64 slice as output data of FP64 tensor
+func (resp *V2Response) GetDoubleVal(outputName string) []float64 {
+	v, _ := resp.data(outputName).([]float64)
+	return v
+}
+
+// GetIntVal returns []int32 slice as output data of INT32 tensor
+func (resp *V2Response) GetIntVal(outputName string) []int32 {
+	v, _ := resp.data(outputName).([]int32)
+	return v
+}
+
+// GetInt64Val returns []int64 slice as output data of INT64 tensor
+func (resp *V2Response) GetInt64Val(outputName string) []int64 {
+	v, _ := resp.data(outputName).([]int64)
+	return v
+}
+
+// GetInt8Val returns []int8 slice as output data of INT8 tensor
+func (resp *V2Response) GetInt8Val(outputName string) []int8 {
+	v, _ := resp.data(outputName).([]int8)
+	return v
+}
+
+// GetInt16Val returns []int16 slice as output data of INT16 tensor
+func (resp *V2Response) GetInt16Val(outputName string) []int16 {
+	v, _ := resp.data(outputName).([]int16)
+	return v
+}
+
+// GetUint8Val returns []uint8 slice as output data of UINT8 tensor
+func (resp *V2Response) GetUint8Val(outputName string) []uint8 {
+	v, _ := resp.data(outputName).([]uint8)
+	return v
+}
+
+// GetUint16Val returns []uint16 slice as output data of UINT16 tensor
+func (resp *V2Response) GetUint16Val(outputName string) []uint16 {
+	v, _ := resp.data(outputName).([]uint16)
+	return v
+}
+
+// GetUint32Val returns []uint32 slice as output data of UINT32 tensor
+func (resp *V2Response) GetUint32Val(outputName string) []uint32 {
+	v, _ := resp.data(outputName).([]uint32)
+	return v
+}
+
+// GetUint64Val returns []uint64 slice as output data of UINT64 tensor
+func (resp *V2Response) GetUint64Val(outputName string) []uint64 {
+	v, _ := resp.data(outputName).([]uint64)
+	return v
+}
+
+// GetBoolVal returns []bool slice as output data of BOOL tensor
+func (resp *V2Response) GetBoolVal(outputName string) []bool {
+	v, _ := resp.data(outputName).([]bool)
+	return v
+}
+
+// GetStringVal returns [][]byte slice as output data of BYTES tensor
+func (resp *V2Response) GetStringVal(outputName string) [][]byte {
+	v, _ := resp.data(outputName).([][]byte)
+	return v
+}
+
+func (resp *V2Response) data(outputName string) interface{} {
+	if output, ok := resp.Output(outputName); ok {
+		return output.Data
+	}
+	return nil
+}
+
+// V2TensorMetadata describes an input or output of a model.
+type V2TensorMetadata struct {
+	Name     string  `json:"name"`
+	Datatype string  `json:"datatype"`
+	Shape    []int64 `json:"shape"`
+}
+
+// V2ModelMetadata is the metadata of a model, dims of variable size are -1 in shapes.
+type V2ModelMetadata struct {
+	Name     string             `json:"name"`
+	Versions []string           `json:"versions,omitempty"`
+	Platform string             `json:"platform"`
+	Inputs   []V2TensorMetadata `json:"inputs"`
+	Outputs  []V2TensorMetadata `json:"outputs"`
+}
+
+// v2ModelPath returns the path of model in inference protocol v2.
+func v2ModelPath(model string, version string) string {
+	path := "/v2/models/" + url.PathEscape(model)
+	if len(version) != 0 {
+		path += "/versions/" + url.PathEscape(version)
+	}
+	return path
+}
+
+// V2Infer sends the request to the model through the open inference protocol v2, used by Triton
+// and KServe processors. Endpoint discovery, signing and retrying are the same as Predict.
+func (p *PredictClient) V2Infer(ctx context.Context, model string, request *V2Request) (*V2Response, error) {
+	data, headerLength, err := request.encode()
+	if err != nil {
+		return nil, NewPredictError(ErrorCodeCreateRequest, "", err.Error())
+	}
+	opts := []PredictOption{WithSubPath(v2ModelPath(model, request.modelVersion) + "/infer")}
+	if headerLength >= 0 {
+		opts = append(opts, WithContentType("application/octet-stream"),
+			WithHeader(headerInferenceHeaderLength, strconv.Itoa(headerLength)))
+	} else {
+		opts = append(opts, WithContentType("application/json"))
+	}
+	body, header, err := p.bytesPredict(ctx, data, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	responseHeaderLength := -1
+	if h := header.Get(headerInferenceHeaderLength); len(h) != 0 {
+		if responseHeaderLength, err = strconv.Atoi(h); err != nil {
+			return nil, NewPredictError(ErrorCodeReadResponse, "", err.Error())
+		}
+	}
+	resp := &V2Response{}
+	if err := resp.decode(body, responseHeaderLength); err != nil {
+		return nil, NewPredictError(ErrorCodeReadResponse, "", err.Error())
+	}
+	return resp, nil
+}
+
+// V2ModelMetadata returns the metadata of model, version is optional.
+func (p *PredictClient) V2ModelMetadata(ctx context.Context, model string, version string) (*V2ModelMetadata, error) {
+	body, err := p.BytesPredictWithContext(ctx, nil, WithMethod(http.MethodGet),
+		WithSubPath(v2ModelPath(model, version)))
+	if err != nil {
+		return nil, err
+	}
+	metadata := &V2ModelMetadata{}
+	if err := json.Unmarshal(body, metadata); err != nil {
+		return nil, NewPredictError(ErrorCodeReadResponse, "", err.Error())
+	}
+	return metadata, nil
+}
+
+// V2ModelReady reports whether the model is ready for inferencing, version is optional.
+func (p *PredictClient) V2ModelReady(ctx context.Context, model string, version string) (bool, error) {
+	return p.v2Ready(ctx, v2ModelPath(model, version)+"/ready")
+}
+
+// V2ServerReady reports whether the inference server is ready.
+func (p *PredictClient) V2ServerReady(ctx context.Context) (bool, error) {
+	return p.v2Ready(ctx, "/v2/health/ready")
+}
+
+// v2Ready checks readiness, servers reply with status 200 when ready, some of them reply
+// {"ready": bool} in the body as well. Not ready is replied with status 400 by Triton and 503 by
+// KServe, other statuses such as 401 or 404 fail with *PredictError. Probes are not retried, as not
+// ready is an answer.
+func (p *PredictClient) v2Ready(ctx context.Context, path string) (bool, error) {
+	body, err := p.BytesPredictWithContext(ctx, nil, WithMethod(http.MethodGet), WithSubPath(path), withoutRetry())
+	if err != nil {
+		if e, ok := err.(*PredictError); ok && (e.Code == http.StatusBadRequest || e.Code == http.StatusServiceUnavailable) {
+			return false, nil
+		}
+		return false, err
+	}
+	status := struct {
+		Ready *bool `json:"ready"`
+	}{}
+	if json.Unmarshal(body, &status) == nil && status.Ready != nil {
+		return *status.Ready, nil
+	}
+	return true, nil
+}
@@ -0,0 +1,159 @@
+package eas
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+func TestV2InferJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/predict/triton/v2/models/resnet/infer" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		request := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&request)
+		input := request["inputs"].([]interface{})[0].(map[string]interface{})
+		if !reflect.DeepEqual(input["data"], []interface{}{1.0, 2.0, 3.0, 4.0}) {
+			t.Errorf("unexpected input data: %v", input["data"])
+		}
+		w.Write([]byte(`{"model_name":"resnet","outputs":[
+			{"name":"prob","shape":[2,2],"datatype":"FP32","data":[[0.5,0.5],[0.25,0.75]]},
+			{"name":"label","shape":[2],"datatype":"BYTES","data":["cat","dog"]},
+			{"name":"id","shape":[1],"datatype":"INT64","data":[9007199254740993]},
+			{"name":"i8","shape":[2],"datatype":"INT8","data":[-1,2]},
+			{"name":"i16","shape":[1],"datatype":"INT16","data":[-300]},
+			{"name":"u16","shape":[1],"datatype":"UINT16","data":[65535]},
+			{"name":"u32","shape":[1],"datatype":"UINT32","data":[4294967295]},
+			{"name":"u64","shape":[1],"datatype":"UINT64","data":[18446744073709551615]}]}`))
+	}))
+	defer server.Close()
+	client := NewPredictClient(server.Listener.Addr().String(), "triton")
+	client.Init()
+
+	req := V2Request{}
+	req.AddFeedFloat32("input", []int64{2, 2}, []float32{1, 2, 3, 4})
+	resp, err := client.V2Infer(context.Background(), "resnet", &req)
+	assertNoError(t, err)
+	if !reflect.DeepEqual(resp.GetFloatVal("prob"), []float32{0.5, 0.5, 0.25, 0.75}) {
+		t.Fatalf("unexpected prob: %v", resp.GetFloatVal("prob"))
+	}
+	assertEqual(t, string(resp.GetStringVal("label")[1]), "dog")
+	assertEqual(t, resp.GetInt64Val("id")[0], int64(9007199254740993))
+	if !reflect.DeepEqual(resp.GetInt8Val("i8"), []int8{-1, 2}) {
+		t.Fatalf("unexpected i8: %v", resp.GetInt8Val("i8"))
+	}
+	assertEqual(t, resp.GetInt16Val("i16")[0], int16(-300))
+	assertEqual(t, resp.GetUint16Val("u16")[0], uint16(65535))
+	assertEqual(t, resp.GetUint32Val("u32")[0], uint32(4294967295))
+	assertEqual(t, resp.GetUint64Val("u64")[0], uint64(18446744073709551615))
+	if !reflect.DeepEqual(resp.GetTensorShape("prob"), []int64{2, 2}) {
+		t.Fatalf("unexpected shape: %v", resp.GetTensorShape("prob"))
+	}
+}
+
+func TestV2InferBinary(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		headerLength, _ := strconv.Atoi(r.Header.Get(headerInferenceHeaderLength))
+		request := V2Request{}
+		json.Unmarshal(body[:headerLength], &request)
+		if request.Outputs[0].Parameters["binary_data"] != true {
+			t.Errorf("binary output is not requested")
+		}
+		raw := body[headerLength:]
+		assertEqual(t, request.Inputs[0].Parameters["binary_data_size"], float64(len(raw)))
+		values := make([]int32, 3)
+		binary.Read(bytes.NewReader(raw), binary.LittleEndian, values)
+		if !reflect.DeepEqual(values, []int32{1, 2, 3}) {
+			t.Errorf("unexpected input: %v", values)
+		}
+
+		out := &bytes.Buffer{}
+		for _, h := range []uint16{float32ToHalf(1.5), float32ToHalf(-2)} {
+			binary.Write(out, binary.LittleEndian, h)
+		}
+		header, _ := json.Marshal(V2Response{ModelName: "m", Outputs: []*V2Tensor{{
+			Name: "out", Shape: []int64{2}, Datatype: V2TypeFP16,
+			Parameters: map[string]interface{}{"binary_data_size": out.Len()},
+		}}})
+		w.Header().Set(headerInferenceHeaderLength, strconv.Itoa(len(header)))
+		w.Write(append(header, out.Bytes()...))
+	}))
+	defer server.Close()
+	client := NewPredictClient(server.Listener.Addr().String(), "triton")
+	client.Init()
+
+	req := V2Request{}
+	req.SetBinaryData(true)
+	req.AddFeedInt32("input", []int64{3}, []int32{1, 2, 3})
+	req.AddFetch("out")
+	resp, err := client.V2Infer(context.Background(), "m", &req)
+	assertNoError(t, err)
+	if !reflect.DeepEqual(resp.GetFloatVal("out"), []float32{1.5, -2}) {
+		t.Fatalf("unexpected output: %v", resp.GetFloatVal("out"))
+	}
+}
+
+func TestV2Ready(t *testing.T) {
+	var probes int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/predict/triton/v2/models/loading_model/ready":
+			atomic.AddInt32(&probes, 1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/api/predict/triton/v2/models/ready_model/ready":
+			w.WriteHeader(http.StatusOK)
+		case "/api/predict/triton/v2/models/unloaded_model/ready":
+			w.WriteHeader(http.StatusBadRequest)
+		case "/api/predict/triton/v2/models/private_model/ready":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/api/predict/triton/v2/models/resnet":
+			w.Write([]byte(`{"name":"resnet","platform":"onnxruntime_onnx","inputs":[{"name":"input","datatype":"FP32","shape":[-1,3,224,224]}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client := NewPredictClient(server.Listener.Addr().String(), "triton")
+	client.Init()
+
+	ready, err := client.V2ModelReady(context.Background(), "ready_model", "")
+	assertNoError(t, err)
+	assertEqual(t, ready, true)
+	ready, err = client.V2ModelReady(context.Background(), "loading_model", "")
+	assertNoError(t, err)
+	assertEqual(t, ready, false)
+	// probes are not retried
+	assertEqual(t, atomic.LoadInt32(&probes), int32(1))
+	ready, err = client.V2ModelReady(context.Background(), "unloaded_model", "")
+	assertNoError(t, err)
+	assertEqual(t, ready, false)
+	// a bad token or a wrong model is not taken as not ready
+	for model, code := range map[string]int{"private_model": http.StatusUnauthorized, "missing_model": http.StatusNotFound} {
+		_, err = client.V2ModelReady(context.Background(), model, "")
+		if e, ok := err.(*PredictError); !ok || e.Code != code {
+			t.Fatalf("expect status %d of %s, got %v", code, model, err)
+		}
+	}
+	metadata, err := client.V2ModelMetadata(context.Background(), "resnet", "")
+	assertNoError(t, err)
+	assertEqual(t, metadata.Inputs[0].Shape[0], int64(-1))
+}
+
+func TestHalfConversion(t *testing.T) {
+	for _, f := range []float32{0, 1, -1, 0.5, 65504, 6.1035156e-05, 5.9604645e-08} {
+		if got := halfToFloat32(float32ToHalf(f)); got != f {
+			t.Fatalf("half round trip of %v got %v", f, got)
+		}
+	}
+	assertEqual(t, float32ToHalf(1e6), uint16(0x7c00))
+}
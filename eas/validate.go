@@ -0,0 +1,165 @@
+package eas
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/pai-eas/eas-golang-sdk/eas/types/tf_predict_protos"
+	"github.com/pai-eas/eas-golang-sdk/eas/types/torch_predict_protos"
+)
+
+// ValidationError is returned when an input tensor of request is inconsistent with its shape or dtype,
+// the request is rejected before it's sent.
+type ValidationError struct {
+	// Input is the name of TFRequest input, or the index of TorchRequest input
+	Input   string
+	Message string
+}
+
+// Error for error interface
+func (err *ValidationError) Error() string {
+	return fmt.Sprintf("invalid input [%s]: %s", err.Input, err.Message)
+}
+
+// ValidatedRequest is implemented by requests able to check themselves before they are sent,
+// Predict validates such requests and returns the *ValidationError without sending them.
+type ValidatedRequest interface {
+	Validate() error
+}
+
+// names of the value fields of ArrayProto
+const (
+	fieldFloatVal  = "float_val"
+	fieldDoubleVal = "double_val"
+	fieldIntVal    = "int_val"
+	fieldInt64Val  = "int64_val"
+	fieldStringVal = "string_val"
+	fieldBoolVal   = "bool_val"
+)
+
+// Validate checks every input of the request: dims are not negative, the dtype is consistent with
+// the populated value field, and the element count equals the product of dims.
+func (tr TFRequest) Validate() error {
+	names := make([]string, 0, len(tr.RequestData.Inputs))
+	for name := range tr.RequestData.Inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		array := tr.RequestData.Inputs[name]
+		if array == nil {
+			return &ValidationError{Input: name, Message: "input is nil"}
+		}
+		field := tfValueField(array.Dtype)
+		fields := map[string]int{
+			fieldFloatVal:  len(array.FloatVal),
+			fieldDoubleVal: len(array.DoubleVal),
+			fieldIntVal:    len(array.IntVal),
+			fieldInt64Val:  len(array.Int64Val),
+			fieldStringVal: len(array.StringVal),
+			fieldBoolVal:   len(array.BoolVal),
+		}
+		if err := validateArray(array.Dtype.String(), field, fields, array.GetArrayShape().GetDim()); err != nil {
+			return &ValidationError{Input: name, Message: err.Error()}
+		}
+	}
+	return nil
+}
+
+// Validate checks every input of the request like TFRequest.Validate, inputs skipped by AddFeed
+// with a larger index are reported as not set.
+func (tr TorchRequest) Validate() error {
+	for i, array := range tr.RequestData.Inputs {
+		input := strconv.Itoa(i)
+		if array == nil || array.Dtype == TorchType_DT_INVALID {
+			return &ValidationError{Input: input, Message: "input is not set"}
+		}
+		field := torchValueField(array.Dtype)
+		fields := map[string]int{
+			fieldFloatVal:  len(array.FloatVal),
+			fieldDoubleVal: len(array.DoubleVal),
+			fieldIntVal:    len(array.IntVal),
+			fieldInt64Val:  len(array.Int64Val),
+			fieldStringVal: len(array.StringVal),
+		}
+		if err := validateArray(array.Dtype.String(), field, fields, array.GetArrayShape().GetDim()); err != nil {
+			return &ValidationError{Input: input, Message: err.Error()}
+		}
+	}
+	return nil
+}
+
+// validateArray checks the populated fields and the element count of an array against its dtype and shape.
+func validateArray(dtype string, field string, fields map[string]int, shape []int64) error {
+	if field == "" {
+		return fmt.Errorf("unsupported dtype %s", dtype)
+	}
+	for name, count := range fields {
+		if name != field && count != 0 {
+			return fmt.Errorf("dtype %s expects values in %s, but %s is populated", dtype, field, name)
+		}
+	}
+	count := int64(fields[field])
+	if dtype == "DT_COMPLEX64" || dtype == "DT_COMPLEX128" {
+		// real and imaginary parts are interleaved
+		if count%2 != 0 {
+			return fmt.Errorf("dtype %s expects an even number of values in %s, got %d", dtype, field, count)
+		}
+		count /= 2
+	}
+	expected := int64(1)
+	for _, dim := range shape {
+		if dim < 0 {
+			return fmt.Errorf("negative dim in shape %v", shape)
+		}
+		expected *= dim
+	}
+	if count != expected {
+		return fmt.Errorf("shape %v expects %d elements, got %d", shape, expected, count)
+	}
+	return nil
+}
+
+// tfValueField returns the field of ArrayProto holding values of the dtype.
+func tfValueField(dtype tf_predict_protos.ArrayDataType) string {
+	switch dtype {
+	case TfType_DT_FLOAT, TfType_DT_COMPLEX64:
+		return fieldFloatVal
+	case TfType_DT_DOUBLE, TfType_DT_COMPLEX128:
+		return fieldDoubleVal
+	case TfType_DT_INT32, TfType_DT_UINT8, TfType_DT_INT16, TfType_DT_INT8, TfType_DT_UINT16,
+		TfType_DT_QINT8, TfType_DT_QUINT8, TfType_DT_QINT16, TfType_DT_QUINT16, TfType_DT_QINT32,
+		TfType_DT_HALF, TfType_DT_BFLOAT16:
+		return fieldIntVal
+	case TfType_DT_INT64:
+		return fieldInt64Val
+	case TfType_DT_STRING:
+		return fieldStringVal
+	case TfType_DT_BOOL:
+		return fieldBoolVal
+	default:
+		return ""
+	}
+}
+
+// torchValueField returns the field of ArrayProto holding values of the dtype, bool is carried by
+// int_val as torch ArrayProto has no bool_val.
+func torchValueField(dtype torch_predict_protos.ArrayDataType) string {
+	switch dtype {
+	case TorchType_DT_FLOAT, TorchType_DT_COMPLEX64:
+		return fieldFloatVal
+	case TorchType_DT_DOUBLE, TorchType_DT_COMPLEX128:
+		return fieldDoubleVal
+	case TorchType_DT_INT32, TorchType_DT_UINT8, TorchType_DT_INT16, TorchType_DT_INT8, TorchType_DT_UINT16,
+		TorchType_DT_QINT8, TorchType_DT_QUINT8, TorchType_DT_QINT16, TorchType_DT_QUINT16, TorchType_DT_QINT32,
+		TorchType_DT_HALF, TorchType_DT_BFLOAT16, TorchType_DT_BOOL:
+		return fieldIntVal
+	case TorchType_DT_INT64:
+		return fieldInt64Val
+	case TorchType_DT_STRING:
+		return fieldStringVal
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,69 @@
+package eas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pai-eas/eas-golang-sdk/eas/types/tf_predict_protos"
+)
+
+func TestTFRequestValidate(t *testing.T) {
+	req := TFRequest{}
+	req.AddFeedFloat32("images", []int64{1, 784}, make([]float32, 784))
+	req.AddFeedComplex64("c", []int64{2}, []complex64{1, 2})
+	assertNoError(t, req.Validate())
+
+	req.AddFeedFloat32("images", []int64{1, 784}, make([]float32, 783))
+	err := req.Validate()
+	if verr, ok := err.(*ValidationError); !ok || verr.Input != "images" {
+		t.Fatalf("element count mismatch should be rejected, got %v", err)
+	}
+
+	req = TFRequest{}
+	req.AddFeedInt32("ids", []int64{-1}, []int32{1, 2})
+	if err = req.Validate(); err == nil || !strings.Contains(err.Error(), "negative") {
+		t.Fatalf("negative dim should be rejected, got %v", err)
+	}
+
+	req = TFRequest{}
+	req.RequestData.Inputs = map[string]*tf_predict_protos.ArrayProto{"x": {
+		Dtype:      TfType_DT_INT64,
+		ArrayShape: &tf_predict_protos.ArrayShape{Dim: []int64{2}},
+		IntVal:     []int32{1, 2},
+	}}
+	if err = req.Validate(); err == nil || !strings.Contains(err.Error(), "int_val is populated") {
+		t.Fatalf("dtype mismatch should be rejected, got %v", err)
+	}
+}
+
+func TestTorchRequestValidate(t *testing.T) {
+	req := TorchRequest{}
+	req.AddFeedBool(1, []int64{2}, []bool{true, false})
+	err := req.Validate()
+	if verr, ok := err.(*ValidationError); !ok || verr.Input != "0" {
+		t.Fatalf("skipped input should be rejected, got %v", err)
+	}
+	req.AddFeedFloat32(0, []int64{1, 3}, []float32{1, 2, 3})
+	assertNoError(t, req.Validate())
+}
+
+func TestPredictValidateNoRetry(t *testing.T) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+	}))
+	defer server.Close()
+	client := NewPredictClient(server.Listener.Addr().String(), "tf")
+	client.Init()
+
+	req := TFRequest{}
+	req.AddFeedFloat32("images", []int64{1, 784}, make([]float32, 10))
+	_, err := client.TFPredict(req)
+	if _, ok := err.(*ValidationError); !ok {
+		t.Fatalf("expect ValidationError, got %v", err)
+	}
+	assertEqual(t, atomic.LoadInt32(&count), int32(0))
+}
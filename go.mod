@@ -5,6 +5,7 @@ go 1.14
 require (
 	github.com/golang/protobuf v1.4.2
 	github.com/google/uuid v1.3.0
+	github.com/klauspost/compress v1.17.0
 	golang.org/x/net v0.0.0-20220728211354-c7608f3a8462
 	google.golang.org/protobuf v1.23.0
 )